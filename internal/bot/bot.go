@@ -0,0 +1,193 @@
+package bot
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"umineko_minesweeper/internal/game"
+	"umineko_minesweeper/internal/solver"
+)
+
+type (
+	Level string
+
+	// Profile sets how a bot plays: how long it thinks before each move and
+	// how often it clicks a cell it has not proved safe.
+	Profile struct {
+		MinDelay    time.Duration
+		MaxDelay    time.Duration
+		MistakeRate float64
+	}
+
+	Move struct {
+		X    int
+		Y    int
+		Flag bool
+	}
+
+	Bot struct {
+		Level   Level
+		Profile Profile
+	}
+)
+
+const (
+	Easy   Level = "easy"
+	Normal Level = "normal"
+	Expert Level = "expert"
+)
+
+var profiles = map[Level]Profile{
+	Easy:   {MinDelay: 1200 * time.Millisecond, MaxDelay: 2500 * time.Millisecond, MistakeRate: 0.08},
+	Normal: {MinDelay: 600 * time.Millisecond, MaxDelay: 1400 * time.Millisecond, MistakeRate: 0.02},
+	Expert: {MinDelay: 250 * time.Millisecond, MaxDelay: 600 * time.Millisecond},
+}
+
+func New(level Level) (*Bot, error) {
+	if level == "" {
+		level = Normal
+	}
+	profile, ok := profiles[level]
+	if !ok {
+		return nil, fmt.Errorf("unknown bot level %q", level)
+	}
+	return &Bot{Level: level, Profile: profile}, nil
+}
+
+// Delay is how long the bot thinks before its next move.
+func (b *Bot) Delay() time.Duration {
+	return b.Profile.MinDelay + rand.N(b.Profile.MaxDelay-b.Profile.MinDelay+1)
+}
+
+// Next picks the bot's next move from what it can see of its own board, and
+// reports false once there is nothing left to click.
+func (b *Bot) Next(g *game.Game, player int) (Move, bool) {
+	state := g.SolverState(player, false)
+	opened := 0
+	for _, row := range state.Revealed {
+		for _, revealed := range row {
+			if revealed {
+				opened++
+			}
+		}
+	}
+
+	var target solver.Point
+	ok := true
+	if opened == 0 {
+		target = solver.Point{X: rand.IntN(state.Width), Y: rand.IntN(state.Height)}
+	} else {
+		deduced := state.Deduce()
+		switch {
+		case rand.Float64() < b.Profile.MistakeRate:
+			target, ok = misclick(state)
+		case len(deduced.Safe) > 0:
+			target = deduced.Safe[rand.IntN(len(deduced.Safe))]
+		default:
+			target, ok = guess(state)
+		}
+	}
+	if !ok {
+		return Move{}, false
+	}
+
+	// A flag, whether the bot's own or one planted by an opponent, blocks the
+	// reveal, so it has to come off first.
+	for _, f := range g.GetPlayerFlags(player) {
+		if f[0] == target.X && f[1] == target.Y {
+			return Move{X: target.X, Y: target.Y, Flag: true}, true
+		}
+	}
+	return Move{X: target.X, Y: target.Y}, true
+}
+
+// misclick picks any unopened cell next to the opened area, as a hurried
+// player might.
+func misclick(s *solver.State) (solver.Point, bool) {
+	var candidates []solver.Point
+	for y := 0; y < s.Height; y++ {
+		for x := 0; x < s.Width; x++ {
+			if s.Revealed[y][x] || s.Known[y][x] {
+				continue
+			}
+			for _, n := range s.Neighbours(x, y) {
+				if s.Revealed[n.Y][n.X] {
+					candidates = append(candidates, solver.Point{X: x, Y: y})
+					break
+				}
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return guess(s)
+	}
+	return candidates[rand.IntN(len(candidates))], true
+}
+
+// guess picks the unknown cell least likely to be a mine. When the position is
+// too complex to solve exactly it falls back to judging frontier cells by their
+// most pessimistic neighbouring number and every other cell by the density of
+// the mines left.
+func guess(s *solver.State) (solver.Point, bool) {
+	if sol, err := s.Solve(); err == nil {
+		best := sol.Safest()
+		if len(best) == 0 {
+			return solver.Point{}, false
+		}
+		return best[rand.IntN(len(best))], true
+	}
+
+	risk := make(map[solver.Point]float64)
+	unknown, known := 0, 0
+	for y := 0; y < s.Height; y++ {
+		for x := 0; x < s.Width; x++ {
+			switch {
+			case s.Known[y][x]:
+				known++
+			case !s.Revealed[y][x]:
+				unknown++
+			default:
+				var cells []solver.Point
+				mines := s.Values[y][x]
+				for _, n := range s.Neighbours(x, y) {
+					if s.Known[n.Y][n.X] {
+						mines--
+					} else if !s.Revealed[n.Y][n.X] {
+						cells = append(cells, n)
+					}
+				}
+				for _, c := range cells {
+					risk[c] = max(risk[c], float64(mines)/float64(len(cells)))
+				}
+			}
+		}
+	}
+	if unknown == 0 {
+		return solver.Point{}, false
+	}
+
+	density := float64(s.Mines-known) / float64(unknown)
+	var best []solver.Point
+	lowest := 2.0
+	for y := 0; y < s.Height; y++ {
+		for x := 0; x < s.Width; x++ {
+			if s.Known[y][x] || s.Revealed[y][x] {
+				continue
+			}
+			p := solver.Point{X: x, Y: y}
+			r, ok := risk[p]
+			if !ok {
+				r = density
+			}
+			if r < lowest {
+				lowest = r
+				best = best[:0]
+			}
+			if r == lowest {
+				best = append(best, p)
+			}
+		}
+	}
+	return best[rand.IntN(len(best))], true
+}
@@ -0,0 +1,118 @@
+package character
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+
+	"umineko_minesweeper/internal/game"
+)
+
+var (
+	//go:embed characters.json
+	defaultCharacters []byte
+
+	validID = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+	knownAbilities = []game.Ability{game.AbilityHide, game.AbilityRedTruth, game.AbilityFakeFlag}
+)
+
+type (
+	Ability struct {
+		ID          game.Ability `json:"id"`
+		Name        string       `json:"name"`
+		Description string       `json:"description,omitempty"`
+	}
+
+	Character struct {
+		ID      string   `json:"id"`
+		Name    string   `json:"name"`
+		Enabled bool     `json:"enabled"`
+		Ability *Ability `json:"ability,omitempty"`
+	}
+
+	Registry struct {
+		characters []Character
+		byID       map[string]Character
+	}
+)
+
+// Load reads the character registry from a JSON file, falling back to the
+// built-in roster when path is empty.
+func Load(path string) (*Registry, error) {
+	raw := defaultCharacters
+	if path != "" {
+		var err error
+		if raw, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	} else {
+		path = "built-in characters"
+	}
+
+	var characters []Character
+	if err := json.Unmarshal(raw, &characters); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	r := &Registry{
+		characters: characters,
+		byID:       make(map[string]Character, len(characters)),
+	}
+	for _, c := range characters {
+		if !validID.MatchString(c.ID) {
+			return nil, fmt.Errorf("%s: invalid character id %q", path, c.ID)
+		}
+		if _, dup := r.byID[c.ID]; dup {
+			return nil, fmt.Errorf("%s: duplicate character %q", path, c.ID)
+		}
+		if c.Name == "" {
+			return nil, fmt.Errorf("%s: character %q has no name", path, c.ID)
+		}
+		if c.Ability != nil && !slices.Contains(knownAbilities, c.Ability.ID) {
+			return nil, fmt.Errorf("%s: character %q has unknown ability %q", path, c.ID, c.Ability.ID)
+		}
+		r.byID[c.ID] = c
+	}
+	return r, nil
+}
+
+// All returns every character in the order they are configured, including
+// disabled ones so clients can show them as unavailable.
+func (r *Registry) All() []Character {
+	return slices.Clone(r.characters)
+}
+
+func (r *Registry) Get(id string) (Character, bool) {
+	c, ok := r.byID[id]
+	return c, ok
+}
+
+// Validate checks that a player may pick the character.
+func (r *Registry) Validate(id string) error {
+	if id == "" {
+		return fmt.Errorf("choose a character")
+	}
+	c, ok := r.byID[id]
+	if !ok {
+		return fmt.Errorf("unknown character %q", id)
+	}
+	if !c.Enabled {
+		return fmt.Errorf("%s is not available", c.Name)
+	}
+	return nil
+}
+
+// Abilities maps each character with an ability to its ability id.
+func (r *Registry) Abilities() map[string]game.Ability {
+	abilities := make(map[string]game.Ability)
+	for _, c := range r.characters {
+		if c.Ability != nil {
+			abilities[c.ID] = c.Ability.ID
+		}
+	}
+	return abilities
+}
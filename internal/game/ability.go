@@ -0,0 +1,228 @@
+package game
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+type (
+	Ability string
+
+	AbilityState struct {
+		Ability  Ability `json:"ability,omitempty"`
+		Charges  int     `json:"charges"`
+		Progress int     `json:"progress"`
+		Cooldown int64   `json:"cooldown"`
+	}
+
+	AbilityResult struct {
+		Ability Ability
+		Player  int
+		Target  int
+		X       int
+		Y       int
+		Until   time.Time
+		Reveal  *RevealResult
+	}
+)
+
+const (
+	AbilityHide     Ability = "hide"
+	AbilityRedTruth Ability = "red_truth"
+	AbilityFakeFlag Ability = "fake_flag"
+	CellsPerCharge          = 30
+	MaxCharges              = 3
+	AbilityCooldown         = 15 * time.Second
+	HideDuration            = 5 * time.Second
+)
+
+// SetAbilities gives each player the ability of the character they picked.
+func (g *Game) SetAbilities(abilities []Ability) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.abilities = make([]Ability, len(g.Players))
+	copy(g.abilities, abilities)
+}
+
+// charge earns a player a new ability charge for every CellsPerCharge cells
+// they reveal, up to MaxCharges.
+func (g *Game) charge(ps *PlayerState, cells int) {
+	if g.abilities == nil {
+		return
+	}
+	ps.chargeProgress += cells
+	for ps.chargeProgress >= CellsPerCharge {
+		ps.chargeProgress -= CellsPerCharge
+		if ps.Charges < MaxCharges {
+			ps.Charges++
+		}
+	}
+	if ps.Charges >= MaxCharges {
+		ps.chargeProgress = 0
+	}
+}
+
+// UseAbility spends one of a player's charges on their character's ability.
+// Bernkastel hides a revealed number on the target's board, Lambdadelta plants
+// a fake flag on one of the target's hidden cells, and Erika reveals a safe
+// cell of her own, so target, x and y are ignored for her.
+func (g *Game) UseAbility(player, target, x, y int) (*AbilityResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.abilities == nil {
+		return nil, fmt.Errorf("abilities are disabled in this room")
+	}
+	if g.State != StatePlaying || !g.Board.IsPlaced() {
+		return nil, fmt.Errorf("abilities unlock once the board is placed")
+	}
+	if player < 0 || player >= len(g.Players) || g.Players[player].Eliminated {
+		return nil, fmt.Errorf("you are out of the game")
+	}
+	ps := g.Players[player]
+	ability := g.abilities[player]
+	if ability == "" {
+		return nil, fmt.Errorf("your character has no ability")
+	}
+	if ps.Charges == 0 {
+		return nil, fmt.Errorf("no ability charges")
+	}
+	now := time.Now()
+	if now.Before(ps.CooldownUntil) || now.Before(g.penalties[player]) {
+		return nil, fmt.Errorf("ability is on cooldown")
+	}
+
+	result := &AbilityResult{
+		Ability: ability,
+		Player:  player,
+		Target:  target,
+		X:       x,
+		Y:       y,
+	}
+
+	switch ability {
+	case AbilityHide, AbilityFakeFlag:
+		if target == player || target < 0 || target >= len(g.Players) || g.Players[target].Eliminated {
+			return nil, fmt.Errorf("invalid target")
+		}
+		if !g.Board.InBounds(x, y) {
+			return nil, fmt.Errorf("invalid cell")
+		}
+		victim := g.Players[target]
+		if ability == AbilityHide {
+			if !victim.Revealed[y][x] || g.Board.GetValue(x, y) <= 0 {
+				return nil, fmt.Errorf("can only hide a revealed number")
+			}
+			result.Until = now.Add(HideDuration)
+			if victim.hidden == nil {
+				victim.hidden = make(map[[2]int]time.Time)
+			}
+			victim.hidden[[2]int{x, y}] = result.Until
+		} else {
+			if victim.Revealed[y][x] || victim.Flagged[y][x] {
+				return nil, fmt.Errorf("can only flag a hidden, unflagged cell")
+			}
+			if g.CapFlags && victim.Flags >= g.Board.Mines {
+				return nil, fmt.Errorf("target has no flags left")
+			}
+			victim.Flagged[y][x] = true
+			victim.Flags++
+			if victim.planted == nil {
+				victim.planted = make(map[[2]int]bool)
+			}
+			victim.planted[[2]int{x, y}] = true
+		}
+
+	case AbilityRedTruth:
+		cell, ok := g.safeCell(ps)
+		if !ok {
+			return nil, fmt.Errorf("no safe cell left to declare")
+		}
+		result.Target = player
+		result.X, result.Y = cell[0], cell[1]
+		cells := g.Board.FloodFill(cell[0], cell[1], ps.Revealed)
+		g.credit(ps, cells)
+		g.scoreCells(ps, cells)
+		result.Reveal = &RevealResult{
+			Player: player,
+			Cells:  cells,
+		}
+		if ps.RevealedCount >= g.Board.TotalSafeCells() {
+			result.Reveal.GameOver = true
+			result.Reveal.Result = g.finish(player, ReasonComplete)
+		}
+	}
+
+	ps.Charges--
+	ps.CooldownUntil = now.Add(AbilityCooldown)
+	action := Action{Type: ActionAbility, Player: player, Ability: ability, Target: result.Target, X: result.X, Y: result.Y}
+	if result.Reveal != nil {
+		action.Cells = result.Reveal.Cells
+	}
+	g.record(action)
+	return result, nil
+}
+
+// safeCell picks an unflagged safe cell the player has not revealed, preferring
+// ones next to cells they have already opened.
+func (g *Game) safeCell(ps *PlayerState) ([2]int, bool) {
+	var frontier, rest [][2]int
+	for y := 0; y < g.Board.Height; y++ {
+		for x := 0; x < g.Board.Width; x++ {
+			if ps.Revealed[y][x] || ps.Flagged[y][x] || g.Board.IsMine(x, y) {
+				continue
+			}
+			if g.touchesRevealed(ps, x, y) {
+				frontier = append(frontier, [2]int{x, y})
+			} else {
+				rest = append(rest, [2]int{x, y})
+			}
+		}
+	}
+	if len(frontier) > 0 {
+		return frontier[rand.IntN(len(frontier))], true
+	}
+	if len(rest) > 0 {
+		return rest[rand.IntN(len(rest))], true
+	}
+	return [2]int{}, false
+}
+
+func (g *Game) touchesRevealed(ps *PlayerState, x, y int) bool {
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			nx, ny := x+dx, y+dy
+			if g.Board.InBounds(nx, ny) && ps.Revealed[ny][nx] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isHidden reports whether Bernkastel is still hiding a cell from the player.
+func (ps *PlayerState) isHidden(x, y int) bool {
+	until, ok := ps.hidden[[2]int{x, y}]
+	return ok && time.Now().Before(until)
+}
+
+// AbilityStates returns each player's ability, charges and remaining cooldown
+// in milliseconds, or nil when abilities are disabled.
+func (g *Game) AbilityStates() []AbilityState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.abilities == nil {
+		return nil
+	}
+	states := make([]AbilityState, len(g.Players))
+	for p, ps := range g.Players {
+		states[p] = AbilityState{
+			Ability:  g.abilities[p],
+			Charges:  ps.Charges,
+			Progress: ps.chargeProgress,
+			Cooldown: max(time.Until(ps.CooldownUntil), 0).Milliseconds(),
+		}
+	}
+	return states
+}
@@ -0,0 +1,41 @@
+package game
+
+import "time"
+
+type (
+	ActionType string
+
+	Action struct {
+		At      int64      `json:"at"`
+		Type    ActionType `json:"type"`
+		Player  int        `json:"player"`
+		X       int        `json:"x"`
+		Y       int        `json:"y"`
+		Cells   []Cell     `json:"cells,omitempty"`
+		Flagged bool       `json:"flagged,omitempty"`
+		Ability Ability    `json:"ability,omitempty"`
+		Target  int        `json:"target,omitempty"`
+	}
+)
+
+const (
+	ActionReveal  ActionType = "reveal"
+	ActionChord   ActionType = "chord"
+	ActionFlag    ActionType = "flag"
+	ActionForfeit ActionType = "forfeit"
+	ActionAbility ActionType = "ability"
+	ActionHint    ActionType = "hint"
+)
+
+func (g *Game) record(action Action) {
+	action.At = time.Since(g.StartedAt).Milliseconds()
+	g.actions = append(g.actions, action)
+}
+
+func (g *Game) Actions() []Action {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	actions := make([]Action, len(g.actions))
+	copy(actions, g.actions)
+	return actions
+}
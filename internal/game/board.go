@@ -1,7 +1,10 @@
 package game
 
 import (
+	"log"
 	"math/rand/v2"
+
+	"umineko_minesweeper/internal/solver"
 )
 
 type (
@@ -14,23 +17,38 @@ type (
 	}
 
 	Board struct {
-		Width  int
-		Height int
-		Mines  int
-		cells  [][]CellValue
-		placed bool
+		Width   int
+		Height  int
+		Mines   int
+		Seed    uint64
+		NoGuess bool
+		cells   [][]CellValue
+		placed  bool
+		// fellBack is set when no-guess generation gave up, until the game
+		// has told the players.
+		fellBack bool
 	}
 )
 
 const (
 	Mine CellValue = -1
+
+	noGuessAttempts = 5000
+
+	// MaxSeed keeps seeds within the range a JavaScript number can hold exactly.
+	MaxSeed = 1<<53 - 1
 )
 
-func NewBoard(width, height, mines int) *Board {
+func NewSeed() uint64 {
+	return rand.Uint64() & MaxSeed
+}
+
+func NewBoard(width, height, mines int, seed uint64) *Board {
 	b := &Board{
 		Width:  width,
 		Height: height,
 		Mines:  mines,
+		Seed:   seed,
 	}
 
 	b.cells = make([][]CellValue, height)
@@ -46,15 +64,37 @@ func (b *Board) EnsurePlaced(safeZones [][2]int) {
 		return
 	}
 	b.placed = true
-	b.placeMines(safeZones)
-	b.calculateAdjacency()
+	rng := rand.New(rand.NewPCG(b.Seed, b.Seed))
+
+	if !b.NoGuess {
+		b.placeMines(rng, safeZones)
+		b.calculateAdjacency()
+		return
+	}
+
+	for attempt := 1; attempt <= noGuessAttempts; attempt++ {
+		b.placeMines(rng, safeZones)
+		b.calculateAdjacency()
+		if b.solvableFrom(safeZones) {
+			return
+		}
+	}
+	log.Printf("no-guess generation gave up after %d attempts (%dx%d, %d mines, seed=%d)", noGuessAttempts, b.Width, b.Height, b.Mines, b.Seed)
+	// The last layout is kept rather than stalling the game, but it may need
+	// guessing, so it is no longer advertised as a no-guess board.
+	b.NoGuess = false
+	b.fellBack = true
 }
 
 func (b *Board) IsPlaced() bool {
 	return b.placed
 }
 
-func (b *Board) placeMines(safeZones [][2]int) {
+func (b *Board) placeMines(rng *rand.Rand, safeZones [][2]int) {
+	for y := 0; y < b.Height; y++ {
+		clear(b.cells[y])
+	}
+
 	excluded := make(map[int]bool)
 	for _, safe := range safeZones {
 		for dy := -1; dy <= 1; dy++ {
@@ -75,7 +115,7 @@ func (b *Board) placeMines(safeZones [][2]int) {
 		}
 	}
 
-	rand.Shuffle(len(candidates), func(i, j int) {
+	rng.Shuffle(len(candidates), func(i, j int) {
 		candidates[i], candidates[j] = candidates[j], candidates[i]
 	})
 
@@ -117,6 +157,29 @@ func (b *Board) calculateAdjacency() {
 	}
 }
 
+func (b *Board) solvableFrom(safeZones [][2]int) bool {
+	for _, start := range safeZones {
+		state := solver.NewState(b.Width, b.Height, b.Mines)
+		revealed := 0
+		queue := []solver.Point{{X: start[0], Y: start[1]}}
+
+		for len(queue) > 0 {
+			for _, p := range queue {
+				for _, cell := range b.FloodFill(p.X, p.Y, state.Revealed) {
+					state.Values[cell.Y][cell.X] = int(cell.Value)
+					revealed++
+				}
+			}
+			queue = state.Deduce().Safe
+		}
+
+		if revealed < b.TotalSafeCells() {
+			return false
+		}
+	}
+	return true
+}
+
 func (b *Board) IsMine(x, y int) bool {
 	return b.cells[y][x] == Mine
 }
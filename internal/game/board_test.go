@@ -0,0 +1,84 @@
+package game
+
+import (
+	"testing"
+
+	"umineko_minesweeper/internal/solver"
+)
+
+// playOut opens the board from start using only the deductions the solver can
+// make, reporting how many safe cells that reached and whether it ever opened
+// a mine.
+func playOut(b *Board, start [2]int) (int, bool) {
+	state := solver.NewState(b.Width, b.Height, b.Mines)
+	opened := 0
+	queue := []solver.Point{{X: start[0], Y: start[1]}}
+	for len(queue) > 0 {
+		for _, p := range queue {
+			if b.IsMine(p.X, p.Y) {
+				return opened, true
+			}
+			for _, cell := range b.FloodFill(p.X, p.Y, state.Revealed) {
+				state.Values[cell.Y][cell.X] = int(cell.Value)
+				opened++
+			}
+		}
+		queue = state.Deduce().Safe
+	}
+	return opened, false
+}
+
+func TestEnsurePlacedNoGuess(t *testing.T) {
+	tests := []struct {
+		name       string
+		difficulty Difficulty
+		start      [2]int
+	}{
+		{"easy from the centre", Easy, [2]int{4, 4}},
+		{"easy from a corner", Easy, [2]int{0, 0}},
+		{"medium from the centre", Medium, [2]int{8, 8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height, mines, err := GetDifficultyConfig(tt.difficulty)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for seed := uint64(1); seed <= 10; seed++ {
+				b := NewBoard(width, height, mines, seed)
+				b.NoGuess = true
+				b.EnsurePlaced([][2]int{tt.start})
+				if !b.NoGuess || b.fellBack {
+					t.Fatalf("seed %d: generation fell back to a board that may need guessing", seed)
+				}
+				for dy := -1; dy <= 1; dy++ {
+					for dx := -1; dx <= 1; dx++ {
+						x, y := tt.start[0]+dx, tt.start[1]+dy
+						if b.InBounds(x, y) && b.IsMine(x, y) {
+							t.Fatalf("seed %d: mine at (%d, %d) next to the first click", seed, x, y)
+						}
+					}
+				}
+				opened, hit := playOut(b, tt.start)
+				if hit {
+					t.Fatalf("seed %d: a deduced safe cell was a mine", seed)
+				}
+				if opened != b.TotalSafeCells() {
+					t.Errorf("seed %d: deductions opened %d of %d safe cells", seed, opened, b.TotalSafeCells())
+				}
+			}
+		})
+	}
+}
+
+func TestEnsurePlacedFallsBack(t *testing.T) {
+	b := NewBoard(10, 10, 35, 1)
+	b.NoGuess = true
+	b.EnsurePlaced([][2]int{{5, 5}})
+	if b.NoGuess || !b.fellBack {
+		t.Errorf("NoGuess = %t, fellBack = %t, want a board no longer marked no-guess", b.NoGuess, b.fellBack)
+	}
+	if !b.IsPlaced() {
+		t.Error("board left without mines")
+	}
+}
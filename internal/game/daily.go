@@ -0,0 +1,68 @@
+package game
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// Daily marks a room as an attempt at the daily challenge for Date, a UTC day
+// in YYYY-MM-DD form. Only ranked attempts count towards its leaderboard.
+type Daily struct {
+	Date   string `json:"date"`
+	Ranked bool   `json:"ranked"`
+}
+
+// DailyDate names the UTC day t falls on.
+func DailyDate(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+// DailySeed derives the board seed every player gets for a difficulty's
+// challenge on the given day.
+func DailySeed(date string, difficulty Difficulty) uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s/%s", date, difficulty)
+	return max(h.Sum64()&MaxSeed, 1)
+}
+
+// IsDailySeed reports whether seed would deal a daily challenge board that is
+// live around now, counting the days either side for players in other time
+// zones.
+func IsDailySeed(seed uint64, now time.Time) bool {
+	for _, day := range []int{-1, 0, 1} {
+		date := DailyDate(now.AddDate(0, 0, day))
+		for difficulty := range difficulties {
+			if DailySeed(date, difficulty) == seed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DailyOptions returns the solo room options for a difficulty's challenge on
+// the given day.
+func DailyOptions(date string, difficulty Difficulty, ranked bool) (RoomOptions, error) {
+	if difficulty == "" {
+		difficulty = Medium
+	}
+	if difficulty == Custom {
+		return RoomOptions{}, fmt.Errorf("daily challenges use a preset difficulty")
+	}
+	if _, _, _, err := GetDifficultyConfig(difficulty); err != nil {
+		return RoomOptions{}, err
+	}
+	return RoomOptions{
+		Mode:       ModeSolo,
+		Difficulty: difficulty,
+		Seed:       DailySeed(date, difficulty),
+		Daily:      &Daily{Date: date, Ranked: ranked},
+	}, nil
+}
+
+// DailyStart is the cell the server opens for every player of a daily
+// challenge, so that the first click cannot shift the mines between players.
+func DailyStart(b *Board) (int, int) {
+	return b.Width / 2, b.Height / 2
+}
@@ -0,0 +1,50 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCreateRoomRejectsDailySeeds(t *testing.T) {
+	now := time.Now()
+	daily := DailySeed(DailyDate(now), Hard)
+	tests := []struct {
+		name string
+		opts RoomOptions
+	}{
+		{"today's seed", RoomOptions{Mode: ModeSolo, Difficulty: Hard, Seed: daily}},
+		{"another difficulty's seed", RoomOptions{Mode: ModeSolo, Difficulty: Easy, Seed: daily}},
+		{"tomorrow's seed", RoomOptions{Mode: ModeSolo, Difficulty: Hard, Seed: DailySeed(DailyDate(now.AddDate(0, 0, 1)), Hard)}},
+		{"dealt in a later round", RoomOptions{Mode: ModeRace, Difficulty: Hard, BestOf: 3, Seed: daily - 1}},
+		{"dealt in the last round", RoomOptions{Mode: ModeRace, Difficulty: Hard, BestOf: 5, Seed: daily - 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := NewRoomManager(nil).CreateRoom(tt.opts); err == nil {
+				t.Errorf("CreateRoom() accepted seed %d", tt.opts.Seed)
+			}
+		})
+	}
+}
+
+func TestCreateRoomAllowsOtherSeeds(t *testing.T) {
+	daily := DailySeed(DailyDate(time.Now()), Hard)
+	tests := []struct {
+		name string
+		opts RoomOptions
+	}{
+		{"daily room", func() RoomOptions {
+			opts, _ := DailyOptions(DailyDate(time.Now()), Hard, true)
+			return opts
+		}()},
+		{"series ending before the daily seed", RoomOptions{Mode: ModeRace, Difficulty: Hard, BestOf: 3, Seed: daily - 3}},
+		{"seed after the daily seed", RoomOptions{Mode: ModeSolo, Difficulty: Hard, Seed: daily + 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := NewRoomManager(nil).CreateRoom(tt.opts); err != nil {
+				t.Errorf("CreateRoom() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package game
+
+// FlagCount is how many of a player's flags turned out right once the game was
+// over.
+type FlagCount struct {
+	Correct   int `json:"correct"`
+	Incorrect int `json:"incorrect"`
+}
+
+// MinesLeft returns each player's mine counter: the board's mines less the
+// flags they have standing and the mines they have set off.
+func (g *Game) MinesLeft() []int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.minesLeft()
+}
+
+func (g *Game) minesLeft() []int {
+	left := make([]int, len(g.Players))
+	for p, ps := range g.Players {
+		left[p] = g.Board.Mines - ps.Flags
+		if !g.Board.IsPlaced() {
+			continue
+		}
+		for y := 0; y < g.Board.Height; y++ {
+			for x := 0; x < g.Board.Width; x++ {
+				if ps.Revealed[y][x] && g.Board.IsMine(x, y) {
+					left[p]--
+				}
+			}
+		}
+	}
+	return left
+}
+
+// unflag takes down the flags a cascade swept over, since an opened cell can
+// no longer hold one.
+func (g *Game) unflag(ps *PlayerState, cells []Cell) {
+	for _, c := range cells {
+		if ps.Flagged[c.Y][c.X] {
+			ps.Flagged[c.Y][c.X] = false
+			ps.Flags--
+			delete(ps.planted, [2]int{c.X, c.Y})
+		}
+	}
+}
+
+// judgeFlags sorts the player's own flags into right and wrong, leaving out any
+// an opponent planted on their board.
+func (g *Game) judgeFlags(ps *PlayerState) FlagCount {
+	var count FlagCount
+	for y := 0; y < g.Board.Height; y++ {
+		for x := 0; x < g.Board.Width; x++ {
+			if !ps.Flagged[y][x] || ps.planted[[2]int{x, y}] {
+				continue
+			}
+			if g.Board.IsMine(x, y) {
+				count.Correct++
+			} else {
+				count.Incorrect++
+			}
+		}
+	}
+	return count
+}
+
+func (g *Game) flagCounts() []FlagCount {
+	counts := make([]FlagCount, len(g.Players))
+	for p, ps := range g.Players {
+		counts[p] = g.judgeFlags(ps)
+	}
+	return counts
+}
@@ -1,40 +1,95 @@
 package game
 
 import (
+	"fmt"
+	"sort"
 	"sync"
+	"time"
+
+	"umineko_minesweeper/internal/solver"
 )
 
 type (
 	GameState int
 
+	Mode string
+
 	GameOverReason string
 
 	PlayerState struct {
 		Revealed      [][]bool
 		Flagged       [][]bool
 		RevealedCount int
+		Flags         int
+		Eliminated    bool
+		Forfeited     bool
+		Lives         int
+		LastRevealAt  time.Time
+		FinishedAt    time.Time
+		Score         Score
+		Charges       int
+		CooldownUntil time.Time
+		// chargeProgress counts cells revealed towards the next charge.
+		chargeProgress int
+		hidden         map[[2]int]time.Time
+		// planted holds fake flags an opponent put on this board, which are
+		// never counted as the player's own.
+		planted map[[2]int]bool
 	}
 
 	GameResult struct {
-		Winner int
-		Loser  int
-		Reason GameOverReason
+		Winner     int            `json:"winner"`
+		Loser      int            `json:"loser"`
+		Reason     GameOverReason `json:"reason"`
+		Placements []int          `json:"placements"`
+		Times      []int64        `json:"times"`
+		Scores     []Score        `json:"scores,omitempty"`
+		Cleared    bool           `json:"cleared,omitempty"`
+		Points     []int          `json:"points,omitempty"`
+		Hints      []int          `json:"hints,omitempty"`
+		Flags      []FlagCount    `json:"flags,omitempty"`
 	}
 
 	RevealResult struct {
-		Player   int
-		Cells    []Cell
-		GameOver bool
-		Result   *GameResult
+		Player     int
+		Cells      []Cell
+		LifeLost   bool
+		Eliminated bool
+		GameOver   bool
+		Result     *GameResult
+		// NoGuessFailed is set on the reveal that placed a no-guess board the
+		// generator could not make guess-free.
+		NoGuessFailed bool
 	}
 
 	Game struct {
-		mu            sync.Mutex
-		Board         *Board
-		State         GameState
-		Players       [2]*PlayerState
-		Code          string
-		pendingClicks [2]*[2]int
+		mu           sync.Mutex
+		Board        *Board
+		State        GameState
+		Mode         Mode
+		Players      []*PlayerState
+		Code         string
+		StartedAt    time.Time
+		FinishedAt   time.Time
+		Result       *GameResult
+		Lives        int
+		MinePenalty  time.Duration
+		TimeLimit    time.Duration
+		Scoring      *ScoringRules
+		TurnTime     time.Duration
+		MineEndsGame bool
+		CapFlags     bool
+		turns        *turnState
+		abilities    []Ability
+		MaxHints     int
+		HintPenalty  time.Duration
+		hints        []int
+		// penalties holds when each seat's penalty runs out, kept per seat
+		// since players on a shared board share one PlayerState.
+		penalties     []time.Time
+		pendingClicks []*[2]int
+		eliminated    []int
+		actions       []Action
 	}
 )
 
@@ -44,22 +99,46 @@ const (
 	StateFinished
 )
 
+func (s GameState) String() string {
+	switch s {
+	case StateWaiting:
+		return "waiting"
+	case StatePlaying:
+		return "playing"
+	case StateFinished:
+		return "finished"
+	}
+	return "unknown"
+}
+
+const (
+	ModeRace  Mode = "race"
+	ModeCoop  Mode = "coop"
+	ModeTurns Mode = "turns"
+	ModeSolo  Mode = "solo"
+)
+
 const (
 	ReasonMineHit  GameOverReason = "mine_hit"
 	ReasonComplete GameOverReason = "completed"
 	ReasonForfeit  GameOverReason = "forfeit"
+	ReasonTimeUp   GameOverReason = "time_up"
 )
 
-func NewGame(code string, width, height, mines int) *Game {
-	board := NewBoard(width, height, mines)
+func NewGame(code string, mode Mode, width, height, mines int, seed uint64, players int) *Game {
+	board := NewBoard(width, height, mines, seed)
 
 	g := &Game{
-		Board: board,
-		State: StateWaiting,
-		Code:  code,
+		Board:         board,
+		State:         StateWaiting,
+		Mode:          mode,
+		Code:          code,
+		Players:       make([]*PlayerState, players),
+		penalties:     make([]time.Time, players),
+		pendingClicks: make([]*[2]int, players),
 	}
 
-	for i := 0; i < 2; i++ {
+	for i := 0; i < players; i++ {
 		g.Players[i] = &PlayerState{
 			Revealed: make([][]bool, height),
 			Flagged:  make([][]bool, height),
@@ -70,6 +149,12 @@ func NewGame(code string, width, height, mines int) *Game {
 		}
 	}
 
+	if mode.SharedBoard() {
+		for i := range g.Players {
+			g.Players[i] = g.Players[0]
+		}
+	}
+
 	return g
 }
 
@@ -77,30 +162,351 @@ func (g *Game) Start() {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 	g.State = StatePlaying
+	g.StartedAt = time.Now()
+	for _, ps := range g.Players {
+		ps.Lives = max(g.Lives, 1)
+	}
+	if g.Mode == ModeTurns {
+		g.startTurns()
+	}
 }
 
-func (g *Game) validateAction(player, x, y int) *PlayerState {
+func (g *Game) alive() []int {
+	var players []int
+	for p, ps := range g.Players {
+		if !ps.Eliminated {
+			players = append(players, p)
+		}
+	}
+	return players
+}
+
+func (g *Game) eliminate(player int) {
+	g.Players[player].Eliminated = true
+	g.Players[player].Lives = 0
+	g.Players[player].FinishedAt = time.Now()
+	g.eliminated = append(g.eliminated, player)
+}
+
+// leaders sorts players by cells revealed, breaking ties in favour of whoever
+// reached their count first.
+func (g *Game) leaders(players []int) {
+	sort.SliceStable(players, func(i, j int) bool {
+		a, b := g.Players[players[i]], g.Players[players[j]]
+		if a.RevealedCount != b.RevealedCount {
+			return a.RevealedCount > b.RevealedCount
+		}
+		return a.LastRevealAt.Before(b.LastRevealAt)
+	})
+}
+
+// standings orders every player for the final result: the winner first, then
+// surviving players by cells revealed, then eliminated players with the most
+// recently eliminated ranked highest. A winner of -1 leaves the order to the
+// other rules.
+func (g *Game) standings(winner int) []int {
+	var placements []int
+	if winner >= 0 {
+		placements = append(placements, winner)
+	}
+
+	var survivors []int
+	for _, p := range g.alive() {
+		if p != winner {
+			survivors = append(survivors, p)
+		}
+	}
+	g.leaders(survivors)
+	placements = append(placements, survivors...)
+
+	for i := len(g.eliminated) - 1; i >= 0; i-- {
+		if g.eliminated[i] != winner {
+			placements = append(placements, g.eliminated[i])
+		}
+	}
+	return placements
+}
+
+// finish ends the game. In scored and turn-based games the final placements
+// follow the points instead.
+func (g *Game) finish(winner int, reason GameOverReason) *GameResult {
+	if !g.Mode.Versus() {
+		return g.finishTeam(reason)
+	}
+
+	var placements []int
+	var points []int
+	if g.turns != nil {
+		placements = g.turnStandings()
+		points = append(points, g.turns.points...)
+	} else {
+		placements = g.standings(winner)
+	}
+	if g.Scoring != nil {
+		if reason == ReasonComplete {
+			g.Players[winner].Score.Completion = g.Scoring.CompletionBonus
+		}
+		g.scoreFlags()
+		g.rankByScore(placements)
+	}
+
+	g.State = StateFinished
+	g.FinishedAt = time.Now()
+	for _, ps := range g.Players {
+		if ps.FinishedAt.IsZero() {
+			ps.FinishedAt = g.FinishedAt
+		}
+	}
+	g.Result = &GameResult{
+		Winner:     placements[0],
+		Loser:      placements[len(placements)-1],
+		Reason:     reason,
+		Placements: placements,
+		Times:      g.times(),
+		Scores:     g.scores(),
+		Points:     points,
+		Hints:      g.hintCounts(),
+		Flags:      g.flagCounts(),
+	}
+	return g.Result
+}
+
+// finishTeam ends a co-op game, which the team wins only by clearing the
+// board.
+func (g *Game) finishTeam(reason GameOverReason) *GameResult {
+	g.State = StateFinished
+	g.FinishedAt = time.Now()
+	g.Players[0].FinishedAt = g.FinishedAt
+	g.Result = &GameResult{
+		Winner:  -1,
+		Loser:   -1,
+		Reason:  reason,
+		Times:   g.times(),
+		Cleared: reason == ReasonComplete,
+		Hints:   g.hintCounts(),
+		Flags:   g.flagCounts(),
+	}
+	return g.Result
+}
+
+// times returns how long each player has been playing in milliseconds, stopping
+// each clock when that player finished or was eliminated.
+func (g *Game) times() []int64 {
+	now := time.Now()
+	times := make([]int64, len(g.Players))
+	for p, ps := range g.Players {
+		end := now
+		if !ps.FinishedAt.IsZero() {
+			end = ps.FinishedAt
+		}
+		times[p] = end.Sub(g.StartedAt).Milliseconds()
+	}
+	return times
+}
+
+// IsPlaced reports whether the mines have been laid yet.
+func (g *Game) IsPlaced() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.Board.IsPlaced()
+}
+
+func (g *Game) GetState() GameState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.State
+}
+
+func (g *Game) Times() []int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.StartedAt.IsZero() {
+		return nil
+	}
+	return g.times()
+}
+
+// Remaining returns the time left before the time limit, or zero when the game
+// has no limit.
+func (g *Game) Remaining() time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.TimeLimit <= 0 || g.StartedAt.IsZero() {
+		return 0
+	}
+	return max(g.TimeLimit-time.Since(g.StartedAt), 0)
+}
+
+// Expire ends a game whose time limit has run out. The surviving player with
+// the most safe cells revealed wins.
+func (g *Game) Expire() []*RevealResult {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.State != StatePlaying || g.TimeLimit <= 0 || time.Since(g.StartedAt) < g.TimeLimit {
+		return nil
+	}
+
+	survivors := g.alive()
+	g.leaders(survivors)
+	return []*RevealResult{{
+		Player:   survivors[0],
+		GameOver: true,
+		Result:   g.finish(survivors[0], ReasonTimeUp),
+	}}
+}
+
+// credit counts safe cells a player has just opened.
+func (g *Game) credit(ps *PlayerState, cells []Cell) {
+	if len(cells) == 0 {
+		return
+	}
+	ps.RevealedCount += len(cells)
+	ps.LastRevealAt = time.Now()
+	g.unflag(ps, cells)
+	g.charge(ps, len(cells))
+}
+
+func (g *Game) playerAt(player, x, y int) *PlayerState {
 	if g.State != StatePlaying {
 		return nil
 	}
-	if player < 0 || player > 1 {
+	if player < 0 || player >= len(g.Players) {
 		return nil
 	}
 	if !g.Board.InBounds(x, y) {
 		return nil
 	}
-
 	ps := g.Players[player]
+	if ps.Eliminated || time.Now().Before(g.penalties[player]) {
+		return nil
+	}
+	if g.turns != nil && g.turns.player != player {
+		return nil
+	}
+	return ps
+}
+
+func (g *Game) validateAction(player, x, y int) *PlayerState {
+	ps := g.playerAt(player, x, y)
+	if ps == nil {
+		return nil
+	}
 	if ps.Revealed[y][x] {
 		return nil
 	}
 	return ps
 }
 
+// mineHit costs a player one life for every mine among cells. A player with
+// lives to spare keeps the mines open on their board and sits out the mine
+// penalty; one who runs out is eliminated. The last player standing wins;
+// otherwise the game carries on for the survivors. Scored and co-op games
+// instead carry on until nobody is left.
+func (g *Game) mineHit(player int, cells []Cell) *RevealResult {
+	if g.turns != nil {
+		return g.turnMineHit(player, cells)
+	}
+
+	ps := g.Players[player]
+	mines := 0
+	for _, c := range cells {
+		if c.Value == Mine {
+			ps.Revealed[c.Y][c.X] = true
+			mines++
+		}
+	}
+	ps.Lives -= mines
+	g.scoreCells(ps, cells)
+	g.scoreMines(ps, mines)
+
+	result := &RevealResult{
+		Player:   player,
+		Cells:    cells,
+		LifeLost: true,
+	}
+	if ps.Lives > 0 {
+		if g.MinePenalty > 0 {
+			g.penalties[player] = time.Now().Add(g.MinePenalty)
+		}
+		return result
+	}
+
+	g.eliminate(player)
+	result.Eliminated = true
+	alive := g.alive()
+	lastStanding := g.Scoring == nil && g.Mode.Versus()
+	if !lastStanding && len(alive) == 0 {
+		result.GameOver = true
+		result.Result = g.finish(player, ReasonMineHit)
+	} else if lastStanding && len(alive) == 1 {
+		result.GameOver = true
+		result.Result = g.finish(alive[0], ReasonMineHit)
+	}
+	return result
+}
+
+// placeIfReady lays the mines once every remaining player has made their
+// first click, keeping each of those clicks safe, and opens them all.
+func (g *Game) placeIfReady() []*RevealResult {
+	var safeZones [][2]int
+	for _, p := range g.alive() {
+		if g.pendingClicks[p] == nil {
+			return nil
+		}
+		safeZones = append(safeZones, *g.pendingClicks[p])
+	}
+
+	g.Board.EnsurePlaced(safeZones)
+	// A solo run is timed from the first click rather than from the room starting.
+	if g.Mode == ModeSolo {
+		g.StartedAt = time.Now()
+	}
+
+	var results []*RevealResult
+	for _, p := range g.alive() {
+		pc := g.pendingClicks[p]
+		pState := g.Players[p]
+		cells := g.Board.FloodFill(pc[0], pc[1], pState.Revealed)
+		g.credit(pState, cells)
+		g.scoreCells(pState, cells)
+		g.record(Action{Type: ActionReveal, Player: p, X: pc[0], Y: pc[1], Cells: cells})
+
+		result := &RevealResult{
+			Player: p,
+			Cells:  cells,
+		}
+
+		if pState.RevealedCount >= g.Board.TotalSafeCells() {
+			result.GameOver = true
+			result.Result = g.finish(p, ReasonComplete)
+			results = append(results, result)
+			break
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
 func (g *Game) Reveal(player, x, y int) []*RevealResult {
 	g.mu.Lock()
 	defer g.mu.Unlock()
+	return g.reportFallback(g.reveal(player, x, y))
+}
+
+// reportFallback flags the first result once a no-guess board has had to
+// settle for a layout that may need guessing, so the room can be told.
+func (g *Game) reportFallback(results []*RevealResult) []*RevealResult {
+	if g.Board.fellBack && len(results) > 0 {
+		results[0].NoGuessFailed = true
+		g.Board.fellBack = false
+	}
+	return results
+}
 
+func (g *Game) reveal(player, x, y int) []*RevealResult {
 	ps := g.validateAction(player, x, y)
 	if ps == nil {
 		return nil
@@ -110,82 +516,115 @@ func (g *Game) Reveal(player, x, y int) []*RevealResult {
 		return nil
 	}
 
+	if !g.Board.IsPlaced() && g.Mode.SharedBoard() {
+		g.Board.EnsurePlaced([][2]int{{x, y}})
+	}
+
 	if !g.Board.IsPlaced() {
 		if g.pendingClicks[player] != nil {
 			return nil
 		}
 		g.pendingClicks[player] = &[2]int{x, y}
+		return g.placeIfReady()
+	}
 
-		other := 1 - player
-		if g.pendingClicks[other] == nil {
-			return nil
-		}
-
-		g.Board.EnsurePlaced([][2]int{
-			{g.pendingClicks[0][0], g.pendingClicks[0][1]},
-			{g.pendingClicks[1][0], g.pendingClicks[1][1]},
-		})
-
-		var results []*RevealResult
-		for p := 0; p < 2; p++ {
-			pc := g.pendingClicks[p]
-			pState := g.Players[p]
-			cells := g.Board.FloodFill(pc[0], pc[1], pState.Revealed)
-			pState.RevealedCount += len(cells)
-
-			result := &RevealResult{
-				Player: p,
-				Cells:  cells,
-			}
-
-			if pState.RevealedCount >= g.Board.TotalSafeCells() {
-				g.State = StateFinished
-				result.GameOver = true
-				result.Result = &GameResult{
-					Winner: p,
-					Loser:  1 - p,
-					Reason: ReasonComplete,
-				}
-				results = append(results, result)
-				break
-			}
+	if g.Board.IsMine(x, y) {
+		cells := []Cell{{X: x, Y: y, Value: Mine}}
+		g.record(Action{Type: ActionReveal, Player: player, X: x, Y: y, Cells: cells})
+		return []*RevealResult{g.mineHit(player, cells)}
+	}
 
-			results = append(results, result)
-		}
+	cells := g.Board.FloodFill(x, y, ps.Revealed)
+	g.credit(ps, cells)
+	g.scoreCells(ps, cells)
+	g.record(Action{Type: ActionReveal, Player: player, X: x, Y: y, Cells: cells})
 
-		return results
+	if g.turns != nil {
+		return []*RevealResult{g.scoreTurn(player, cells)}
 	}
 
-	if g.Board.IsMine(x, y) {
-		g.State = StateFinished
-		opponent := 1 - player
+	if ps.RevealedCount >= g.Board.TotalSafeCells() {
 		return []*RevealResult{{
 			Player:   player,
-			Cells:    []Cell{{X: x, Y: y, Value: Mine}},
+			Cells:    cells,
 			GameOver: true,
-			Result: &GameResult{
-				Winner: opponent,
-				Loser:  player,
-				Reason: ReasonMineHit,
-			},
+			Result:   g.finish(player, ReasonComplete),
 		}}
 	}
 
-	cells := g.Board.FloodFill(x, y, ps.Revealed)
-	ps.RevealedCount += len(cells)
+	return []*RevealResult{{
+		Player: player,
+		Cells:  cells,
+	}}
+}
+
+func (g *Game) Chord(player, x, y int) []*RevealResult {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ps := g.playerAt(player, x, y)
+	if ps == nil {
+		return nil
+	}
+	if !ps.Revealed[y][x] || ps.isHidden(x, y) {
+		return nil
+	}
+
+	value := g.Board.GetValue(x, y)
+	if value <= 0 {
+		return nil
+	}
 
+	flags := 0
+	var targets [][2]int
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if dy == 0 && dx == 0 {
+				continue
+			}
+			nx, ny := x+dx, y+dy
+			if !g.Board.InBounds(nx, ny) {
+				continue
+			}
+			if (ps.Flagged[ny][nx] && !ps.Revealed[ny][nx]) || (ps.Revealed[ny][nx] && g.Board.IsMine(nx, ny)) {
+				flags++
+			} else if !ps.Revealed[ny][nx] {
+				targets = append(targets, [2]int{nx, ny})
+			}
+		}
+	}
+	if flags != int(value) || len(targets) == 0 {
+		return nil
+	}
+
+	var cells []Cell
+	var mines []Cell
+	for _, t := range targets {
+		if g.Board.IsMine(t[0], t[1]) {
+			mines = append(mines, Cell{X: t[0], Y: t[1], Value: Mine})
+			continue
+		}
+		cells = append(cells, g.Board.FloodFill(t[0], t[1], ps.Revealed)...)
+	}
+	g.credit(ps, cells)
+	cells = append(cells, mines...)
+	g.record(Action{Type: ActionChord, Player: player, X: x, Y: y, Cells: cells})
+
+	if len(mines) > 0 {
+		// mineHit scores the safe cells along with the mines.
+		return []*RevealResult{g.mineHit(player, cells)}
+	}
+
+	g.scoreCells(ps, cells)
+	if g.turns != nil {
+		return []*RevealResult{g.scoreTurn(player, cells)}
+	}
 	if ps.RevealedCount >= g.Board.TotalSafeCells() {
-		g.State = StateFinished
-		opponent := 1 - player
 		return []*RevealResult{{
 			Player:   player,
 			Cells:    cells,
 			GameOver: true,
-			Result: &GameResult{
-				Winner: player,
-				Loser:  opponent,
-				Reason: ReasonComplete,
-			},
+			Result:   g.finish(player, ReasonComplete),
 		}}
 	}
 
@@ -195,18 +634,60 @@ func (g *Game) Reveal(player, x, y int) []*RevealResult {
 	}}
 }
 
-func (g *Game) Flag(player, x, y int) *bool {
+// Flag toggles a flag on one of the player's hidden cells and reports whether
+// it now stands. Actions that are not allowed right now are ignored with a nil
+// result; running out of flags under the cap is an error the player sees.
+func (g *Game) Flag(player, x, y int) (*bool, error) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
 	ps := g.validateAction(player, x, y)
 	if ps == nil {
-		return nil
+		return nil, nil
+	}
+
+	if !ps.Flagged[y][x] && g.CapFlags && ps.Flags >= g.Board.Mines {
+		return nil, fmt.Errorf("no flags left")
 	}
 
 	ps.Flagged[y][x] = !ps.Flagged[y][x]
 	flagged := ps.Flagged[y][x]
-	return &flagged
+	if flagged {
+		ps.Flags++
+	} else {
+		ps.Flags--
+		delete(ps.planted, [2]int{x, y})
+	}
+	g.record(Action{Type: ActionFlag, Player: player, X: x, Y: y, Flagged: flagged})
+	return &flagged, nil
+}
+
+// SolverState describes what a player can see of their board. Mines they have
+// set off count as known; their flags only do when trustFlags is set, since a
+// flag is just the player's guess.
+func (g *Game) SolverState(player int, trustFlags bool) *solver.State {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.solverState(player, trustFlags)
+}
+
+func (g *Game) solverState(player int, trustFlags bool) *solver.State {
+	ps := g.Players[player]
+	state := solver.NewState(g.Board.Width, g.Board.Height, g.Board.Mines)
+	for y := 0; y < g.Board.Height; y++ {
+		for x := 0; x < g.Board.Width; x++ {
+			switch {
+			case ps.Revealed[y][x] && g.Board.IsMine(x, y):
+				state.Known[y][x] = true
+			case ps.Revealed[y][x]:
+				state.Revealed[y][x] = true
+				state.Values[y][x] = int(g.Board.GetValue(x, y))
+			case ps.Flagged[y][x] && trustFlags:
+				state.Known[y][x] = true
+			}
+		}
+	}
+	return state
 }
 
 func (g *Game) GetPlayerCells(player int) []Cell {
@@ -245,19 +726,76 @@ func (g *Game) GetPlayerFlags(player int) [][2]int {
 	return flags
 }
 
-func (g *Game) Forfeit(player int) *GameResult {
+// PlayerLives returns each player's remaining lives, or nil when the game is
+// not played with lives.
+func (g *Game) PlayerLives() []int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.Lives <= 1 {
+		return nil
+	}
+	lives := make([]int, len(g.Players))
+	for p, ps := range g.Players {
+		lives[p] = ps.Lives
+	}
+	return lives
+}
+
+func (g *Game) EliminatedPlayers() []int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	eliminated := make([]int, len(g.eliminated))
+	copy(eliminated, g.eliminated)
+	return eliminated
+}
+
+// Forfeit eliminates a player who left the game. It ends the game if at most
+// one player remains, and may place the board if the others were only waiting on
+// the forfeiting player's first click.
+func (g *Game) Forfeit(player int) []*RevealResult {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
 	if g.State != StatePlaying {
 		return nil
 	}
+	if player < 0 || player >= len(g.Players) || g.Players[player].Eliminated {
+		return nil
+	}
 
-	g.State = StateFinished
-	opponent := 1 - player
-	return &GameResult{
-		Winner: opponent,
-		Loser:  player,
-		Reason: ReasonForfeit,
+	if g.turns != nil {
+		return g.turnForfeit(player)
+	}
+
+	// A co-op player who leaves takes nothing from the team's shared board.
+	if g.Mode == ModeCoop {
+		g.record(Action{Type: ActionForfeit, Player: player})
+		return []*RevealResult{{
+			Player:     player,
+			Eliminated: true,
+		}}
+	}
+
+	g.eliminate(player)
+	g.Players[player].Forfeited = true
+	g.record(Action{Type: ActionForfeit, Player: player})
+	results := []*RevealResult{{
+		Player:     player,
+		Eliminated: true,
+	}}
+
+	if alive := g.alive(); len(alive) <= 1 {
+		winner := -1
+		if len(alive) == 1 {
+			winner = alive[0]
+		}
+		results[0].GameOver = true
+		results[0].Result = g.finish(winner, ReasonForfeit)
+		return results
+	}
+
+	if !g.Board.IsPlaced() {
+		results = append(results, g.placeIfReady()...)
 	}
+	return g.reportFallback(results)
 }
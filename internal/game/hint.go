@@ -0,0 +1,174 @@
+package game
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"umineko_minesweeper/internal/solver"
+)
+
+const (
+	MaxHints       = 10
+	MaxHintPenalty = 30 * time.Second
+
+	// hintAttempts bounds how often a hint is worked out again when the
+	// board changes under the solver.
+	hintAttempts = 3
+)
+
+// Hint points a player at an unrevealed cell. Safe is set when the cell is
+// certainly safe; otherwise Probability is the chance it hides a mine, as no
+// safe cell can be deduced from what the player has opened.
+type Hint struct {
+	X           int     `json:"x"`
+	Y           int     `json:"y"`
+	Safe        bool    `json:"safe"`
+	Probability float64 `json:"probability"`
+	Left        int     `json:"left"`
+}
+
+// Hint gives the player a cell they can open safely, or the least risky one
+// when none is certain, charging them the room's hint penalty.
+func (g *Game) Hint(player int) (*Hint, error) {
+	for range hintAttempts {
+		state, flagged, err := g.hintSnapshot(player)
+		if err != nil {
+			return nil, err
+		}
+		// Solving a large board can take a while, so it works on a snapshot
+		// rather than holding up every other player.
+		hint, err := findHint(state, flagged)
+		if err != nil {
+			return nil, err
+		}
+		if ok, err := g.chargeHint(player, hint); err != nil || ok {
+			return hint, err
+		}
+	}
+	return nil, fmt.Errorf("the board changed while finding a hint, try again")
+}
+
+// hintSnapshot copies what the player can see of their board for the solver.
+func (g *Game) hintSnapshot(player int) (*solver.State, map[solver.Point]bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.checkHint(player); err != nil {
+		return nil, nil, err
+	}
+	ps := g.Players[player]
+	flagged := make(map[solver.Point]bool)
+	for y := 0; y < g.Board.Height; y++ {
+		for x := 0; x < g.Board.Width; x++ {
+			if ps.Flagged[y][x] {
+				flagged[solver.Point{X: x, Y: y}] = true
+			}
+		}
+	}
+	return g.solverState(player, false), flagged, nil
+}
+
+// chargeHint hands the hint over at the cost of the room's penalty. It
+// reports false when the player opened or flagged the cell while the solver
+// ran, so the hint can be worked out again.
+func (g *Game) chargeHint(player int, hint *Hint) (bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.checkHint(player); err != nil {
+		return false, err
+	}
+	ps := g.Players[player]
+	if ps.Revealed[hint.Y][hint.X] || ps.Flagged[hint.Y][hint.X] {
+		return false, nil
+	}
+
+	g.hints[player]++
+	hint.Left = g.MaxHints - g.hints[player]
+	if g.HintPenalty > 0 {
+		g.penalties[player] = time.Now().Add(g.HintPenalty)
+	}
+	if g.Scoring != nil {
+		ps.Score.Hints -= g.Scoring.HintPenalty
+		ps.Score.total()
+	}
+	g.record(Action{Type: ActionHint, Player: player, X: hint.X, Y: hint.Y})
+	return true, nil
+}
+
+func (g *Game) checkHint(player int) error {
+	if g.MaxHints == 0 {
+		return fmt.Errorf("hints are disabled in this room")
+	}
+	if g.State != StatePlaying || player < 0 || player >= len(g.Players) {
+		return fmt.Errorf("game is not in progress")
+	}
+	ps := g.Players[player]
+	if ps.Eliminated {
+		return fmt.Errorf("you are out of the game")
+	}
+	if time.Now().Before(g.penalties[player]) {
+		return fmt.Errorf("wait out your penalty first")
+	}
+	if g.turns != nil && g.turns.player != player {
+		return fmt.Errorf("not your turn")
+	}
+	if !g.Board.IsPlaced() {
+		return fmt.Errorf("every cell is safe on the first click")
+	}
+	if g.hints == nil {
+		g.hints = make([]int, len(g.Players))
+	}
+	if g.hints[player] >= g.MaxHints {
+		return fmt.Errorf("no hints left")
+	}
+	return nil
+}
+
+// findHint prefers a cell the player could have deduced is safe, then the one
+// the solver rates least likely to be a mine, passing over cells the player
+// has flagged. A position too complex to solve exactly gets no hint rather
+// than one read off the mine layout.
+func findHint(state *solver.State, flagged map[solver.Point]bool) (*Hint, error) {
+	var safe []solver.Point
+	for _, p := range state.Deduce().Safe {
+		if !flagged[p] {
+			safe = append(safe, p)
+		}
+	}
+	if len(safe) > 0 {
+		p := safe[rand.IntN(len(safe))]
+		return &Hint{X: p.X, Y: p.Y, Safe: true}, nil
+	}
+
+	sol, err := state.Solve()
+	if err != nil {
+		return nil, fmt.Errorf("no hint is available for this position")
+	}
+	// Safest passes over opened cells, so flagged ones are marked the same.
+	for p := range flagged {
+		sol.Probability[p.Y][p.X] = -1
+	}
+	best := sol.Safest()
+	if len(best) == 0 {
+		return nil, fmt.Errorf("no cells left to open")
+	}
+	p := best[rand.IntN(len(best))]
+	return &Hint{X: p.X, Y: p.Y, Probability: sol.Probability[p.Y][p.X]}, nil
+}
+
+// Hints returns how many hints each player has used, or nil when hints are
+// disabled.
+func (g *Game) Hints() []int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.hintCounts()
+}
+
+func (g *Game) hintCounts() []int {
+	if g.MaxHints == 0 {
+		return nil
+	}
+	counts := make([]int, len(g.Players))
+	copy(counts, g.hints)
+	return counts
+}
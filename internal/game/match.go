@@ -0,0 +1,66 @@
+package game
+
+import "fmt"
+
+const MaxBestOf = 7
+
+type Match struct {
+	BestOf  int
+	Round   int
+	Scores  []int
+	Winner  int
+	rematch []bool
+	settled bool
+}
+
+func NewMatch(bestOf, players int) *Match {
+	return &Match{
+		BestOf:  bestOf,
+		Round:   1,
+		Scores:  make([]int, players),
+		Winner:  -1,
+		rematch: make([]bool, players),
+	}
+}
+
+func ValidateBestOf(bestOf int) error {
+	if bestOf < 1 || bestOf > MaxBestOf || bestOf%2 == 0 {
+		return fmt.Errorf("best of must be an odd number between 1 and %d", MaxBestOf)
+	}
+	return nil
+}
+
+// Snapshot copies the match so it can be read outside the room manager's lock.
+func (m *Match) Snapshot() Match {
+	snapshot := *m
+	snapshot.Scores = append([]int(nil), m.Scores...)
+	snapshot.rematch = nil
+	return snapshot
+}
+
+func (m *Match) Over() bool {
+	return m.Winner >= 0 || m.settled
+}
+
+func (m *Match) WinsNeeded() int {
+	return m.BestOf/2 + 1
+}
+
+func (m *Match) Record(result *GameResult) {
+	if m.Over() {
+		return
+	}
+	// Co-op games have no winner and are always a single round.
+	if result.Winner < 0 {
+		m.settled = true
+		return
+	}
+	if result.Reason == ReasonForfeit {
+		m.Winner = result.Winner
+		return
+	}
+	m.Scores[result.Winner]++
+	if m.Scores[result.Winner] >= m.WinsNeeded() {
+		m.Winner = result.Winner
+	}
+}
@@ -3,7 +3,9 @@ package game
 import (
 	"fmt"
 	"math/rand/v2"
+	"sort"
 	"sync"
+	"time"
 )
 
 type Difficulty string
@@ -12,8 +14,28 @@ const (
 	Easy   Difficulty = "easy"
 	Medium Difficulty = "medium"
 	Hard   Difficulty = "hard"
+	Custom Difficulty = "custom"
 
 	CodeLength = 6
+
+	MinBoardSize   = 5
+	MaxBoardWidth  = 100
+	MaxBoardHeight = 100
+	MaxMineDensity = 0.35
+
+	// Custom no-guess boards are held to the hard preset's size and density,
+	// beyond which generation rarely finds a guess-free layout in time.
+	MaxNoGuessCells   = 30 * 16
+	MaxNoGuessDensity = 0.21
+
+	MinPlayers = 2
+	MaxPlayers = 8
+
+	MaxLives       = 9
+	MaxMinePenalty = 30 * time.Second
+
+	MinTimeLimit = 30 * time.Second
+	MaxTimeLimit = time.Hour
 )
 
 type difficultyConfig struct {
@@ -28,64 +50,361 @@ var difficulties = map[Difficulty]difficultyConfig{
 	Hard:   {Width: 30, Height: 16, Mines: 99},
 }
 
-func GetDifficultyConfig(d Difficulty) (int, int, int) {
+func GetDifficultyConfig(d Difficulty) (int, int, int, error) {
+	if d == "" {
+		d = Medium
+	}
 	cfg, ok := difficulties[d]
 	if !ok {
-		cfg = difficulties[Medium]
+		return 0, 0, 0, fmt.Errorf("unknown difficulty %q", d)
 	}
-	return cfg.Width, cfg.Height, cfg.Mines
+	return cfg.Width, cfg.Height, cfg.Mines, nil
+}
+
+// ValidateCustomBoard checks a custom board's bounds, mine density, and that
+// every player's 3x3 safe zone still fits around the mines.
+func ValidateCustomBoard(width, height, mines, players int) error {
+	if width < MinBoardSize || width > MaxBoardWidth {
+		return fmt.Errorf("width must be between %d and %d", MinBoardSize, MaxBoardWidth)
+	}
+	if height < MinBoardSize || height > MaxBoardHeight {
+		return fmt.Errorf("height must be between %d and %d", MinBoardSize, MaxBoardHeight)
+	}
+	if mines < 1 {
+		return fmt.Errorf("mines must be at least 1")
+	}
+	maxMines := int(float64(width*height) * MaxMineDensity)
+	if free := width*height - 9*players; free < maxMines {
+		maxMines = free
+	}
+	if mines > maxMines {
+		return fmt.Errorf("too many mines for a %dx%d board (max %d)", width, height, maxMines)
+	}
+	return nil
 }
 
 type (
+	RoomOptions struct {
+		Mode         Mode
+		Difficulty   Difficulty
+		Width        int
+		Height       int
+		Mines        int
+		NoGuess      bool
+		Seed         uint64
+		BestOf       int
+		Ranked       bool
+		Public       bool
+		MaxPlayers   int
+		Lives        int
+		MinePenalty  time.Duration
+		TimeLimit    time.Duration
+		Scoring      *ScoringRules
+		TurnTime     time.Duration
+		MineEndsGame bool
+		CapFlags     bool
+		Abilities    bool
+		Daily        *Daily
+		Bot          string
+		Hints        int
+		HintPenalty  time.Duration
+	}
+
 	Room struct {
 		Game         *Game
+		Match        *Match
+		Options      RoomOptions
+		CreatedAt    time.Time
 		PlayerCount  int
-		PlayerTokens [2]string
-		Characters   [2]string
+		PlayerTokens []string
+		Characters   []string
+		ProfileIDs   []string
+	}
+
+	RoomSummary struct {
+		Code          string     `json:"code"`
+		Mode          Mode       `json:"mode"`
+		Difficulty    Difficulty `json:"difficulty"`
+		Width         int        `json:"width"`
+		Height        int        `json:"height"`
+		Mines         int        `json:"mines"`
+		NoGuess       bool       `json:"noGuess"`
+		BestOf        int        `json:"bestOf"`
+		Lives         int        `json:"lives"`
+		TimeLimit     int64      `json:"timeLimit"`
+		Scoring       bool       `json:"scoring"`
+		CapFlags      bool       `json:"capFlags"`
+		Abilities     bool       `json:"abilities"`
+		Hints         int        `json:"hints"`
+		Players       int        `json:"players"`
+		MaxPlayers    int        `json:"maxPlayers"`
+		HostCharacter string     `json:"hostCharacter"`
+		CreatedAt     time.Time  `json:"createdAt"`
+		Age           int64      `json:"age"`
+		Spectators    int        `json:"spectators"`
 	}
 
 	RoomManager struct {
-		mu    sync.RWMutex
-		rooms map[string]*Room
+		mu        sync.RWMutex
+		rooms     map[string]*Room
+		abilities map[string]Ability
 	}
 )
 
-func NewRoomManager() *RoomManager {
+func (o RoomOptions) boardSize() (int, int, int, error) {
+	if o.Difficulty != Custom {
+		width, height, mines, err := GetDifficultyConfig(o.Difficulty)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if width*height-9*o.MaxPlayers < mines {
+			return 0, 0, 0, fmt.Errorf("%s board is too small for %d players", o.Difficulty, o.MaxPlayers)
+		}
+		return width, height, mines, nil
+	}
+	if err := ValidateCustomBoard(o.Width, o.Height, o.Mines, o.MaxPlayers); err != nil {
+		return 0, 0, 0, err
+	}
+	return o.Width, o.Height, o.Mines, nil
+}
+
+// NewRoomManager takes the ability each character brings to games played with
+// abilities enabled.
+func NewRoomManager(abilities map[string]Ability) *RoomManager {
 	return &RoomManager{
-		rooms: make(map[string]*Room),
+		rooms:     make(map[string]*Room),
+		abilities: abilities,
 	}
 }
 
-func (rm *RoomManager) CreateRoom(difficulty Difficulty) (*Room, string) {
+func (rm *RoomManager) CreateRoom(opts RoomOptions) (*Room, string, error) {
+	switch opts.Mode {
+	case "":
+		opts.Mode = ModeRace
+	case ModeRace, ModeCoop, ModeTurns, ModeSolo:
+	default:
+		return nil, "", fmt.Errorf("unknown mode %q", opts.Mode)
+	}
+
+	if opts.Mode == ModeSolo {
+		if opts.MaxPlayers > 1 {
+			return nil, "", fmt.Errorf("solo games have a single player")
+		}
+		opts.MaxPlayers = 1
+	}
+	if opts.MaxPlayers == 0 {
+		opts.MaxPlayers = MinPlayers
+	}
+	if opts.Mode != ModeSolo && (opts.MaxPlayers < MinPlayers || opts.MaxPlayers > MaxPlayers) {
+		return nil, "", fmt.Errorf("players must be between %d and %d", MinPlayers, MaxPlayers)
+	}
+
+	width, height, mines, err := opts.boardSize()
+	if err != nil {
+		return nil, "", err
+	}
+	opts.Width, opts.Height, opts.Mines = width, height, mines
+	if opts.NoGuess && opts.Difficulty == Custom {
+		if width*height > MaxNoGuessCells {
+			return nil, "", fmt.Errorf("no-guess boards are limited to %d cells", MaxNoGuessCells)
+		}
+		if float64(mines) > float64(width*height)*MaxNoGuessDensity {
+			return nil, "", fmt.Errorf("no-guess boards are limited to %d%% mines", int(MaxNoGuessDensity*100))
+		}
+	}
+
+	if opts.BestOf == 0 {
+		opts.BestOf = 1
+	}
+	if err := ValidateBestOf(opts.BestOf); err != nil {
+		return nil, "", err
+	}
+	if opts.MaxPlayers > MinPlayers && (opts.BestOf > 1 || opts.Ranked) {
+		return nil, "", fmt.Errorf("series and ranked games are limited to %d players", MinPlayers)
+	}
+
+	if opts.Lives == 0 {
+		opts.Lives = 1
+	}
+	if opts.Lives < 1 || opts.Lives > MaxLives {
+		return nil, "", fmt.Errorf("lives must be between 1 and %d", MaxLives)
+	}
+	if opts.MinePenalty < 0 || opts.MinePenalty > MaxMinePenalty {
+		return nil, "", fmt.Errorf("mine penalty must be between 0 and %v", MaxMinePenalty)
+	}
+	if opts.TimeLimit != 0 && (opts.TimeLimit < MinTimeLimit || opts.TimeLimit > MaxTimeLimit) {
+		return nil, "", fmt.Errorf("time limit must be between %v and %v", MinTimeLimit, MaxTimeLimit)
+	}
+	if opts.Mode == ModeCoop && (opts.BestOf > 1 || opts.Ranked || opts.Scoring != nil) {
+		return nil, "", fmt.Errorf("co-op games cannot be series, ranked or scored")
+	}
+	if opts.Bot != "" && (opts.Mode != ModeRace || opts.MaxPlayers != MinPlayers || opts.Ranked || opts.Public) {
+		return nil, "", fmt.Errorf("bots can only race in private %d-player rooms", MinPlayers)
+	}
+	if opts.Daily != nil && opts.Mode != ModeSolo {
+		return nil, "", fmt.Errorf("daily challenges are played solo")
+	}
+	if opts.Daily == nil && opts.Seed != 0 {
+		// Each round of a series is dealt the next seed along.
+		for round := 0; round < opts.BestOf; round++ {
+			if IsDailySeed((opts.Seed+uint64(round))&MaxSeed, time.Now()) {
+				return nil, "", fmt.Errorf("that seed is reserved for the daily challenge")
+			}
+		}
+	}
+	if opts.Mode == ModeSolo && (opts.BestOf > 1 || opts.Ranked || opts.Scoring != nil || opts.Public) {
+		return nil, "", fmt.Errorf("solo games cannot be series, ranked, scored or public")
+	}
+	if opts.Mode == ModeTurns {
+		if opts.Lives > 1 || opts.Scoring != nil {
+			return nil, "", fmt.Errorf("turn-based games cannot use lives or scoring")
+		}
+		if opts.TurnTime == 0 {
+			opts.TurnTime = DefaultTurnTime
+		}
+		if opts.TurnTime < MinTurnTime || opts.TurnTime > MaxTurnTime {
+			return nil, "", fmt.Errorf("turn time must be between %v and %v", MinTurnTime, MaxTurnTime)
+		}
+	}
+	if opts.Hints < 0 || opts.Hints > MaxHints {
+		return nil, "", fmt.Errorf("hints must be between 0 and %d", MaxHints)
+	}
+	if opts.HintPenalty < 0 || opts.HintPenalty > MaxHintPenalty {
+		return nil, "", fmt.Errorf("hint penalty must be between 0 and %v", MaxHintPenalty)
+	}
+	if opts.Abilities && opts.Mode != ModeRace {
+		return nil, "", fmt.Errorf("abilities are only available in race games")
+	}
+	if opts.Scoring != nil {
+		if *opts.Scoring == (ScoringRules{}) {
+			rules := DefaultScoring
+			opts.Scoring = &rules
+		}
+		if err := ValidateScoring(*opts.Scoring); err != nil {
+			return nil, "", err
+		}
+	}
+
 	rm.mu.Lock()
 	defer rm.mu.Unlock()
 
 	code := rm.generateCode()
-	width, height, mines := GetDifficultyConfig(difficulty)
-	game := NewGame(code, width, height, mines)
-
 	room := &Room{
-		Game:        game,
-		PlayerCount: 1,
+		Match:        NewMatch(opts.BestOf, opts.MaxPlayers),
+		Options:      opts,
+		CreatedAt:    time.Now().UTC(),
+		PlayerCount:  1,
+		PlayerTokens: make([]string, opts.MaxPlayers),
+		Characters:   make([]string, opts.MaxPlayers),
+		ProfileIDs:   make([]string, opts.MaxPlayers),
 	}
+	room.Game = room.newGame(code)
 	rm.rooms[code] = room
 
-	return room, code
+	return room, code, nil
 }
 
-func (rm *RoomManager) JoinRoom(code string) (*Room, error) {
+func (r *Room) newGame(code string) *Game {
+	seed := NewSeed()
+	if r.Options.Seed != 0 {
+		seed = (r.Options.Seed + uint64(r.Match.Round-1)) & MaxSeed
+	}
+	game := NewGame(code, r.Options.Mode, r.Options.Width, r.Options.Height, r.Options.Mines, seed, r.PlayerCount)
+	game.Board.NoGuess = r.Options.NoGuess
+	game.Lives = r.Options.Lives
+	game.MinePenalty = r.Options.MinePenalty
+	game.TimeLimit = r.Options.TimeLimit
+	game.Scoring = r.Options.Scoring
+	game.TurnTime = r.Options.TurnTime
+	game.MineEndsGame = r.Options.MineEndsGame
+	game.CapFlags = r.Options.CapFlags
+	game.MaxHints = r.Options.Hints
+	game.HintPenalty = r.Options.HintPenalty
+	return game
+}
+
+func (r *Room) start(abilities map[string]Ability) {
+	if r.Options.Abilities {
+		seated := make([]Ability, r.PlayerCount)
+		for p, character := range r.SeatedCharacters() {
+			seated[p] = abilities[character]
+		}
+		r.Game.SetAbilities(seated)
+	}
+	r.Game.Start()
+}
+
+func (r *Room) Full() bool {
+	return r.PlayerCount >= r.Options.MaxPlayers
+}
+
+// SeatedCharacters returns the characters of the players currently seated.
+func (r *Room) SeatedCharacters() []string {
+	return r.Characters[:r.PlayerCount]
+}
+
+// JoinRoom seats a new player and returns their player number.
+func (rm *RoomManager) JoinRoom(code string) (*Room, int, error) {
 	rm.mu.Lock()
 	defer rm.mu.Unlock()
 
 	room, exists := rm.rooms[code]
 	if !exists {
-		return nil, fmt.Errorf("room not found")
+		return nil, -1, fmt.Errorf("room not found")
+	}
+	if room.Game.GetState() != StateWaiting {
+		return nil, -1, fmt.Errorf("game already started")
 	}
-	if room.PlayerCount >= 2 {
-		return nil, fmt.Errorf("room is full")
+	if room.Full() {
+		return nil, -1, fmt.Errorf("room is full")
 	}
 
 	room.PlayerCount++
+	return room, room.PlayerCount - 1, nil
+}
+
+// LeaveRoom frees a seat in a room that has not started yet. Players seated
+// after the leaver move down one seat.
+func (rm *RoomManager) LeaveRoom(code string, player int) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	room, exists := rm.rooms[code]
+	if !exists || room.Game.GetState() != StateWaiting || player <= 0 || player >= room.PlayerCount {
+		return
+	}
+
+	for _, seats := range [][]string{room.PlayerTokens, room.Characters, room.ProfileIDs} {
+		copy(seats[player:], seats[player+1:])
+		seats[len(seats)-1] = ""
+	}
+	room.PlayerCount--
+}
+
+// StartGame begins the first round once at least two players are seated,
+// sizing the game to the players actually present.
+func (rm *RoomManager) StartGame(code string) (*Room, error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	room, exists := rm.rooms[code]
+	if !exists {
+		return nil, fmt.Errorf("room not found")
+	}
+	if room.Game.GetState() != StateWaiting {
+		return nil, fmt.Errorf("game already started")
+	}
+	if room.PlayerCount < MinPlayers && room.Options.Mode != ModeSolo {
+		return nil, fmt.Errorf("need at least %d players", MinPlayers)
+	}
+
+	if len(room.Game.Players) != room.PlayerCount {
+		room.Game = room.newGame(code)
+	}
+	if len(room.Match.Scores) != room.PlayerCount {
+		room.Match = NewMatch(room.Options.BestOf, room.PlayerCount)
+	}
+	room.start(rm.abilities)
 	return room, nil
 }
 
@@ -95,6 +414,18 @@ func (rm *RoomManager) GetRoom(code string) *Room {
 	return rm.rooms[code]
 }
 
+// Game returns the room's current round. A rematch swaps in a new game under
+// the manager's lock, so callers outside it must go through here rather than
+// reading Room.Game.
+func (rm *RoomManager) Game(code string) *Game {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	if room, exists := rm.rooms[code]; exists {
+		return room.Game
+	}
+	return nil
+}
+
 func (rm *RoomManager) RemoveRoom(code string) {
 	rm.mu.Lock()
 	defer rm.mu.Unlock()
@@ -105,7 +436,7 @@ func (rm *RoomManager) FindByToken(token string) (*Room, string, int) {
 	rm.mu.RLock()
 	defer rm.mu.RUnlock()
 	for code, room := range rm.rooms {
-		for i := 0; i < 2; i++ {
+		for i := 0; i < room.PlayerCount; i++ {
 			if room.PlayerTokens[i] == token {
 				return room, code, i
 			}
@@ -130,6 +461,14 @@ func (rm *RoomManager) SetCharacter(code string, player int, character string) {
 	}
 }
 
+func (rm *RoomManager) SetProfile(code string, player int, profileID string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	if room, exists := rm.rooms[code]; exists {
+		room.ProfileIDs[player] = profileID
+	}
+}
+
 func (rm *RoomManager) GetHostCharacter(code string) string {
 	rm.mu.RLock()
 	defer rm.mu.RUnlock()
@@ -139,6 +478,143 @@ func (rm *RoomManager) GetHostCharacter(code string) string {
 	return ""
 }
 
+func (rm *RoomManager) GetMatch(code string) (Match, bool) {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	if room, exists := rm.rooms[code]; exists {
+		return room.Match.Snapshot(), true
+	}
+	return Match{}, false
+}
+
+func (rm *RoomManager) RecordResult(code string, result *GameResult) Match {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	room, exists := rm.rooms[code]
+	if !exists {
+		return Match{}
+	}
+	room.Match.Record(result)
+	return room.Match.Snapshot()
+}
+
+// Forfeit removes a player who left for good. Mid-round this eliminates them
+// from the game; between rounds of a series it concedes the match.
+func (rm *RoomManager) Forfeit(code string, player int) []*RevealResult {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	room, exists := rm.rooms[code]
+	if !exists {
+		return nil
+	}
+	if room.Game.GetState() == StatePlaying {
+		return room.Game.Forfeit(player)
+	}
+	if room.Game.GetState() != StateFinished || room.Match.Over() || len(room.Match.Scores) != MinPlayers {
+		return nil
+	}
+	return []*RevealResult{{
+		Player:     player,
+		Eliminated: true,
+		GameOver:   true,
+		Result: &GameResult{
+			Winner:     1 - player,
+			Loser:      player,
+			Reason:     ReasonForfeit,
+			Placements: []int{1 - player, player},
+		},
+	}}
+}
+
+// RequestRematch marks a player as ready for the next round of the match and
+// starts it once both players have asked.
+func (rm *RoomManager) RequestRematch(code string, player int) (bool, error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	room, exists := rm.rooms[code]
+	if !exists {
+		return false, fmt.Errorf("room not found")
+	}
+	if room.Game.GetState() != StateFinished {
+		return false, fmt.Errorf("round still in progress")
+	}
+	if room.Match.Over() {
+		return false, fmt.Errorf("match is over")
+	}
+
+	room.Match.rematch[player] = true
+	for _, ready := range room.Match.rematch {
+		if !ready {
+			return false, nil
+		}
+	}
+
+	clear(room.Match.rematch)
+	room.Match.Round++
+	room.Game = room.newGame(code)
+	room.start(rm.abilities)
+	return true, nil
+}
+
+func (r *Room) open() bool {
+	return r.Options.Public && r.Game.GetState() == StateWaiting && !r.Full()
+}
+
+func (r *Room) summary(code string) RoomSummary {
+	difficulty := r.Options.Difficulty
+	if difficulty == "" {
+		difficulty = Medium
+	}
+	return RoomSummary{
+		Code:          code,
+		Mode:          r.Options.Mode,
+		Difficulty:    difficulty,
+		Width:         r.Options.Width,
+		Height:        r.Options.Height,
+		Mines:         r.Options.Mines,
+		NoGuess:       r.Options.NoGuess,
+		BestOf:        r.Options.BestOf,
+		Lives:         r.Options.Lives,
+		TimeLimit:     r.Options.TimeLimit.Milliseconds(),
+		Scoring:       r.Options.Scoring != nil,
+		CapFlags:      r.Options.CapFlags,
+		Abilities:     r.Options.Abilities,
+		Hints:         r.Options.Hints,
+		Players:       r.PlayerCount,
+		MaxPlayers:    r.Options.MaxPlayers,
+		HostCharacter: r.Characters[0],
+		CreatedAt:     r.CreatedAt,
+	}
+}
+
+func (rm *RoomManager) OpenPublicRoom(code string) (RoomSummary, bool) {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	room, exists := rm.rooms[code]
+	if !exists || !room.open() {
+		return RoomSummary{}, false
+	}
+	return room.summary(code), true
+}
+
+// OpenPublicRooms lists public rooms still waiting for an opponent, oldest
+// first.
+func (rm *RoomManager) OpenPublicRooms() []RoomSummary {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	rooms := []RoomSummary{}
+	for code, room := range rm.rooms {
+		if room.open() {
+			rooms = append(rooms, room.summary(code))
+		}
+	}
+	sort.Slice(rooms, func(i, j int) bool {
+		return rooms[i].CreatedAt.Before(rooms[j].CreatedAt)
+	})
+	return rooms
+}
+
 func (rm *RoomManager) generateCode() string {
 	const charset = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
 	for {
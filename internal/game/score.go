@@ -0,0 +1,118 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+)
+
+const MaxScoringPoints = 100
+
+type (
+	ScoringRules struct {
+		CellPoints       int `json:"cellPoints"`
+		NumberPoints     int `json:"numberPoints"`
+		FlagBonus        int `json:"flagBonus"`
+		WrongFlagPenalty int `json:"wrongFlagPenalty"`
+		MinePenalty      int `json:"minePenalty"`
+		CompletionBonus  int `json:"completionBonus"`
+		HintPenalty      int `json:"hintPenalty"`
+	}
+
+	Score struct {
+		Reveals    int `json:"reveals"`
+		Flags      int `json:"flags"`
+		WrongFlags int `json:"wrongFlags"`
+		Mines      int `json:"mines"`
+		Completion int `json:"completion"`
+		Hints      int `json:"hints"`
+		Total      int `json:"total"`
+	}
+)
+
+var DefaultScoring = ScoringRules{
+	CellPoints:       1,
+	NumberPoints:     1,
+	FlagBonus:        5,
+	WrongFlagPenalty: 10,
+	MinePenalty:      25,
+	CompletionBonus:  50,
+	HintPenalty:      20,
+}
+
+func ValidateScoring(rules ScoringRules) error {
+	for _, points := range []int{rules.CellPoints, rules.NumberPoints, rules.FlagBonus, rules.WrongFlagPenalty, rules.MinePenalty, rules.CompletionBonus, rules.HintPenalty} {
+		if points < 0 || points > MaxScoringPoints {
+			return fmt.Errorf("scoring points must be between 0 and %d", MaxScoringPoints)
+		}
+	}
+	return nil
+}
+
+func (s *Score) total() {
+	s.Total = s.Reveals + s.Flags + s.WrongFlags + s.Mines + s.Completion + s.Hints
+}
+
+// scoreCells credits a player for safe cells they opened, weighting each cell
+// by the number on it.
+func (g *Game) scoreCells(ps *PlayerState, cells []Cell) {
+	if g.Scoring == nil {
+		return
+	}
+	for _, c := range cells {
+		if c.Value != Mine {
+			ps.Score.Reveals += g.Scoring.CellPoints + int(c.Value)*g.Scoring.NumberPoints
+		}
+	}
+	ps.Score.total()
+}
+
+func (g *Game) scoreMines(ps *PlayerState, mines int) {
+	if g.Scoring == nil {
+		return
+	}
+	ps.Score.Mines -= mines * g.Scoring.MinePenalty
+	ps.Score.total()
+}
+
+// scoreFlags settles every player's flags once the game is over. Flags are
+// only judged at the end so the score never gives away which flags are right.
+func (g *Game) scoreFlags() {
+	for _, ps := range g.Players {
+		count := g.judgeFlags(ps)
+		ps.Score.Flags += count.Correct * g.Scoring.FlagBonus
+		ps.Score.WrongFlags -= count.Incorrect * g.Scoring.WrongFlagPenalty
+		ps.Score.total()
+	}
+}
+
+// rankByScore reorders placements by total score, keeping the existing order
+// between players on the same score. Players who forfeited always place below
+// those who played on, so leaving while ahead never pays.
+func (g *Game) rankByScore(placements []int) {
+	sort.SliceStable(placements, func(i, j int) bool {
+		a, b := g.Players[placements[i]], g.Players[placements[j]]
+		if a.Forfeited != b.Forfeited {
+			return b.Forfeited
+		}
+		return a.Score.Total > b.Score.Total
+	})
+}
+
+func (g *Game) scores() []Score {
+	if g.Scoring == nil {
+		return nil
+	}
+	scores := make([]Score, len(g.Players))
+	for p, ps := range g.Players {
+		scores[p] = ps.Score
+	}
+	return scores
+}
+
+// Scores returns every player's score breakdown, or nil when the game is not
+// scored.
+func (g *Game) Scores() []Score {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.scores()
+}
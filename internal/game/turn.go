@@ -0,0 +1,210 @@
+package game
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	TurnMinePenalty = 5
+
+	DefaultTurnTime = 20 * time.Second
+	MinTurnTime     = 5 * time.Second
+	MaxTurnTime     = 2 * time.Minute
+)
+
+type (
+	// Turn is a snapshot of whose go it is in a turn-based game.
+	Turn struct {
+		Player    int   `json:"player"`
+		Number    int   `json:"number"`
+		Remaining int64 `json:"remaining"`
+		Points    []int `json:"points"`
+	}
+
+	turnState struct {
+		player   int
+		number   int
+		deadline time.Time
+		points   []int
+		out      []bool
+	}
+)
+
+// SharedBoard reports whether every player in the mode acts on one board.
+func (m Mode) SharedBoard() bool {
+	return m == ModeCoop || m == ModeTurns
+}
+
+// Versus reports whether players in the mode compete against each other rather
+// than simply clearing the board or not.
+func (m Mode) Versus() bool {
+	return m != ModeCoop && m != ModeSolo
+}
+
+func (g *Game) startTurns() {
+	g.turns = &turnState{
+		player:   0,
+		number:   1,
+		deadline: time.Now().Add(g.TurnTime),
+		points:   make([]int, len(g.Players)),
+		out:      make([]bool, len(g.Players)),
+	}
+}
+
+// nextTurn hands the turn to the next player still in the game.
+func (g *Game) nextTurn() {
+	t := g.turns
+	for i := 1; i <= len(t.out); i++ {
+		next := (t.player + i) % len(t.out)
+		if !t.out[next] {
+			t.player = next
+			break
+		}
+	}
+	t.number++
+	t.deadline = time.Now().Add(g.TurnTime)
+}
+
+func (g *Game) inTurn() []int {
+	var players []int
+	for p, out := range g.turns.out {
+		if !out {
+			players = append(players, p)
+		}
+	}
+	return players
+}
+
+// scoreTurn credits the active player for the safe cells they opened and
+// passes the turn on, unless that cleared the board.
+func (g *Game) scoreTurn(player int, cells []Cell) *RevealResult {
+	g.turns.points[player] += len(cells)
+
+	if g.Players[player].RevealedCount >= g.Board.TotalSafeCells() {
+		return &RevealResult{
+			Player:   player,
+			Cells:    cells,
+			GameOver: true,
+			Result:   g.finish(player, ReasonComplete),
+		}
+	}
+
+	g.nextTurn()
+	return &RevealResult{
+		Player: player,
+		Cells:  cells,
+	}
+}
+
+// turnMineHit leaves the mines open on the shared board and either ends the
+// game against the player who hit them or docks their points and passes the
+// turn.
+func (g *Game) turnMineHit(player int, cells []Cell) *RevealResult {
+	ps := g.Players[player]
+	safe, mines := 0, 0
+	for _, c := range cells {
+		if c.Value == Mine {
+			ps.Revealed[c.Y][c.X] = true
+			mines++
+		} else {
+			safe++
+		}
+	}
+	g.turns.points[player] += safe
+
+	if g.MineEndsGame {
+		g.turns.out[player] = true
+		return &RevealResult{
+			Player:     player,
+			Cells:      cells,
+			Eliminated: true,
+			GameOver:   true,
+			Result:     g.finish(g.inTurn()[0], ReasonMineHit),
+		}
+	}
+
+	g.turns.points[player] -= mines * TurnMinePenalty
+	if ps.RevealedCount >= g.Board.TotalSafeCells() {
+		return &RevealResult{
+			Player:   player,
+			Cells:    cells,
+			GameOver: true,
+			Result:   g.finish(player, ReasonComplete),
+		}
+	}
+
+	g.nextTurn()
+	return &RevealResult{
+		Player: player,
+		Cells:  cells,
+	}
+}
+
+// turnStandings places players by points, with anyone who left or lost the
+// game on a mine below everyone still in it.
+func (g *Game) turnStandings() []int {
+	placements := make([]int, len(g.Players))
+	for p := range placements {
+		placements[p] = p
+	}
+	t := g.turns
+	sort.SliceStable(placements, func(i, j int) bool {
+		a, b := placements[i], placements[j]
+		if t.out[a] != t.out[b] {
+			return t.out[b]
+		}
+		return t.points[a] > t.points[b]
+	})
+	return placements
+}
+
+// turnForfeit takes a player who left out of the rotation, ending the game
+// once a single player is left in it.
+func (g *Game) turnForfeit(player int) []*RevealResult {
+	if g.turns.out[player] {
+		return nil
+	}
+	g.turns.out[player] = true
+	g.record(Action{Type: ActionForfeit, Player: player})
+	result := &RevealResult{
+		Player:     player,
+		Eliminated: true,
+	}
+
+	if remaining := g.inTurn(); len(remaining) == 1 {
+		result.GameOver = true
+		result.Result = g.finish(remaining[0], ReasonForfeit)
+	} else if g.turns.player == player {
+		g.nextTurn()
+	}
+	return []*RevealResult{result}
+}
+
+// ExpireTurn passes the turn on when the active player has run out of time.
+func (g *Game) ExpireTurn() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.turns == nil || g.State != StatePlaying || time.Now().Before(g.turns.deadline) {
+		return false
+	}
+	g.nextTurn()
+	return true
+}
+
+// Turn returns the current turn, or nil when the game is not turn-based.
+func (g *Game) Turn() *Turn {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.turns == nil {
+		return nil
+	}
+	return &Turn{
+		Player:    g.turns.player,
+		Number:    g.turns.number,
+		Remaining: max(time.Until(g.turns.deadline), 0).Milliseconds(),
+		Points:    append([]int(nil), g.turns.points...),
+	}
+}
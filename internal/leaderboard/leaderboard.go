@@ -0,0 +1,120 @@
+package leaderboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const MaxEntries = 100
+
+var validBoard = regexp.MustCompile(`^[a-z0-9-]+(/[a-z0-9-]+)*$`)
+
+type (
+	Member struct {
+		ProfileID string `json:"profileId,omitempty"`
+		Name      string `json:"name,omitempty"`
+		Character string `json:"character"`
+	}
+
+	Entry struct {
+		Members  []Member  `json:"members"`
+		Time     int64     `json:"time"`
+		ReplayID string    `json:"replayId,omitempty"`
+		PlayedAt time.Time `json:"playedAt"`
+	}
+
+	Store struct {
+		mu     sync.RWMutex
+		path   string
+		boards map[string][]Entry
+	}
+)
+
+// Board names a leaderboard, e.g. Board("coop", "easy") is "coop/easy".
+func Board(parts ...string) string {
+	return strings.Join(parts, "/")
+}
+
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:   path,
+		boards: make(map[string][]Entry),
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(raw, &s.boards); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+	return s, nil
+}
+
+// Submit adds a run to a board and returns its 1-based rank, or 0 if it was
+// too slow to make the board.
+func (s *Store) Submit(board string, entry Entry) (int, error) {
+	if !validBoard.MatchString(board) {
+		return 0, fmt.Errorf("invalid leaderboard %q", board)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := s.boards[board]
+	rank := sort.Search(len(entries), func(i int) bool {
+		return entries[i].Time > entry.Time
+	})
+	if rank >= MaxEntries {
+		return 0, nil
+	}
+
+	entries = append(entries, Entry{})
+	copy(entries[rank+1:], entries[rank:])
+	entries[rank] = entry
+	if len(entries) > MaxEntries {
+		entries = entries[:MaxEntries]
+	}
+	s.boards[board] = entries
+
+	return rank + 1, s.save()
+}
+
+// Top returns up to limit of the fastest runs on a board.
+func (s *Store) Top(board string, limit int) []Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := s.boards[board]
+	if limit <= 0 || limit > len(entries) {
+		limit = len(entries)
+	}
+	top := make([]Entry, limit)
+	copy(top, entries)
+	return top
+}
+
+func (s *Store) save() error {
+	raw, err := json.Marshal(s.boards)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,297 @@
+package profile
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"umineko_minesweeper/internal/game"
+	"umineko_minesweeper/internal/rating"
+)
+
+const MaxNameLength = 24
+
+type (
+	Profile struct {
+		ID        string    `json:"id"`
+		Name      string    `json:"name"`
+		KeyHash   string    `json:"keyHash"`
+		Rating    float64   `json:"rating"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+
+	MatchRecord struct {
+		ReplayID     string              `json:"replayId,omitempty"`
+		OpponentID   string              `json:"opponentId,omitempty"`
+		OpponentName string              `json:"opponentName,omitempty"`
+		Character    string              `json:"character"`
+		Won          bool                `json:"won"`
+		Mode         game.Mode           `json:"mode,omitempty"`
+		Placement    int                 `json:"placement,omitempty"`
+		Players      int                 `json:"players,omitempty"`
+		Reason       game.GameOverReason `json:"reason"`
+		Duration     int64               `json:"duration"`
+		Width        int                 `json:"width"`
+		Height       int                 `json:"height"`
+		Mines        int                 `json:"mines"`
+		PlayedAt     time.Time           `json:"playedAt"`
+	}
+
+	Stats struct {
+		ID                    string        `json:"id"`
+		Name                  string        `json:"name"`
+		Rating                float64       `json:"rating"`
+		Wins                  int           `json:"wins"`
+		Losses                int           `json:"losses"`
+		FavouriteCharacter    string        `json:"favouriteCharacter,omitempty"`
+		AverageCompletionTime int64         `json:"averageCompletionTime"`
+		History               []MatchRecord `json:"history"`
+	}
+
+	// dailyAttempts lists the difficulties a player has made their ranked
+	// attempt at on the most recent day they played the daily challenge.
+	dailyAttempts struct {
+		Date         string            `json:"date"`
+		Difficulties []game.Difficulty `json:"difficulties"`
+	}
+
+	storeData struct {
+		Profiles map[string]*Profile       `json:"profiles"`
+		History  map[string][]MatchRecord  `json:"history"`
+		Daily    map[string]*dailyAttempts `json:"daily,omitempty"`
+	}
+
+	Store struct {
+		mu    sync.RWMutex
+		path  string
+		data  storeData
+		byKey map[string]string
+	}
+)
+
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path: path,
+		data: storeData{
+			Profiles: make(map[string]*Profile),
+			History:  make(map[string][]MatchRecord),
+			Daily:    make(map[string]*dailyAttempts),
+		},
+		byKey: make(map[string]string),
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(raw, &s.data); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+
+	if s.data.Daily == nil {
+		s.data.Daily = make(map[string]*dailyAttempts)
+	}
+	for id, p := range s.data.Profiles {
+		s.byKey[p.KeyHash] = id
+		if p.Rating == 0 {
+			p.Rating = rating.Default
+		}
+	}
+	return s, nil
+}
+
+func (s *Store) Register(name string) (*Profile, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > MaxNameLength {
+		return nil, "", fmt.Errorf("name must be between 1 and %d characters", MaxNameLength)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := randomHex(32)
+	p := &Profile{
+		ID:        randomHex(8),
+		Name:      name,
+		KeyHash:   hashKey(key),
+		Rating:    rating.Default,
+		CreatedAt: time.Now().UTC(),
+	}
+	s.data.Profiles[p.ID] = p
+	s.byKey[p.KeyHash] = p.ID
+
+	if err := s.save(); err != nil {
+		return nil, "", err
+	}
+	copied := *p
+	return &copied, key, nil
+}
+
+func (s *Store) Authenticate(key string) (*Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.byKey[hashKey(key)]
+	if !ok {
+		return nil, fmt.Errorf("unknown player key")
+	}
+	p := *s.data.Profiles[id]
+	return &p, nil
+}
+
+func (s *Store) Get(id string) (*Profile, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.data.Profiles[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *p
+	return &copied, true
+}
+
+func (s *Store) RecordMatch(id string, record MatchRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data.Profiles[id]; !ok {
+		return fmt.Errorf("player not found")
+	}
+	s.data.History[id] = append(s.data.History[id], record)
+	return s.save()
+}
+
+// StartDaily claims a player's one ranked attempt at a daily challenge,
+// reporting false if they have already used it.
+func (s *Store) StartDaily(id, date string, difficulty game.Difficulty) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data.Profiles[id]; !ok {
+		return false, fmt.Errorf("player not found")
+	}
+	attempts := s.data.Daily[id]
+	if attempts == nil || attempts.Date != date {
+		attempts = &dailyAttempts{Date: date}
+		s.data.Daily[id] = attempts
+	}
+	if slices.Contains(attempts.Difficulties, difficulty) {
+		return false, nil
+	}
+	attempts.Difficulties = append(attempts.Difficulties, difficulty)
+	return true, s.save()
+}
+
+// ApplyResult updates both players' ratings after a ranked game and returns
+// the winner's and loser's new ratings. It only updates memory, so the caller
+// can settle ratings without waiting on the disk and persist them with Save.
+func (s *Store) ApplyResult(winnerID, loserID string) (float64, float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	winner, ok := s.data.Profiles[winnerID]
+	if !ok {
+		return 0, 0, fmt.Errorf("player not found")
+	}
+	loser, ok := s.data.Profiles[loserID]
+	if !ok {
+		return 0, 0, fmt.Errorf("player not found")
+	}
+
+	winner.Rating, loser.Rating = rating.Update(winner.Rating, loser.Rating)
+	return winner.Rating, loser.Rating, nil
+}
+
+// Save writes the store to disk.
+func (s *Store) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.save()
+}
+
+func (s *Store) Stats(id string) (*Stats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.data.Profiles[id]
+	if !ok {
+		return nil, fmt.Errorf("player not found")
+	}
+
+	history := s.data.History[id]
+	stats := &Stats{
+		ID:      p.ID,
+		Name:    p.Name,
+		Rating:  p.Rating,
+		History: make([]MatchRecord, len(history)),
+	}
+	copy(stats.History, history)
+
+	characters := make(map[string]int)
+	var completionTotal int64
+	completions := 0
+	for _, r := range history {
+		if r.Won {
+			stats.Wins++
+		} else {
+			stats.Losses++
+		}
+		if r.Character != "" {
+			characters[r.Character]++
+		}
+		if r.Won && r.Reason == game.ReasonComplete {
+			completionTotal += r.Duration
+			completions++
+		}
+	}
+
+	best := 0
+	for character, count := range characters {
+		if count > best || (count == best && character < stats.FavouriteCharacter) {
+			best = count
+			stats.FavouriteCharacter = character
+		}
+	}
+	if completions > 0 {
+		stats.AverageCompletionTime = completionTotal / int64(completions)
+	}
+	return stats, nil
+}
+
+func (s *Store) save() error {
+	raw, err := json.Marshal(s.data)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,19 @@
+package rating
+
+import "math"
+
+const (
+	Default = 1500.0
+	KFactor = 32.0
+)
+
+// Expected returns the probability that a player rated a beats a player rated b.
+func Expected(a, b float64) float64 {
+	return 1 / (1 + math.Pow(10, (b-a)/400))
+}
+
+// Update returns the new ratings of the winner and loser of a single game.
+func Update(winner, loser float64) (float64, float64) {
+	delta := KFactor * (1 - Expected(winner, loser))
+	return winner + delta, loser - delta
+}
@@ -0,0 +1,59 @@
+package rating
+
+import (
+	"math"
+	"testing"
+)
+
+const epsilon = 1e-9
+
+func TestExpectedSymmetry(t *testing.T) {
+	tests := []struct {
+		a, b float64
+	}{
+		{1500, 1500},
+		{1500, 1600},
+		{1200, 2000},
+		{2400, 800},
+	}
+	for _, tt := range tests {
+		sum := Expected(tt.a, tt.b) + Expected(tt.b, tt.a)
+		if math.Abs(sum-1) > epsilon {
+			t.Errorf("Expected(%v, %v) + Expected(%v, %v) = %v, want 1", tt.a, tt.b, tt.b, tt.a, sum)
+		}
+	}
+	if got := Expected(1500, 1500); math.Abs(got-0.5) > epsilon {
+		t.Errorf("Expected(1500, 1500) = %v, want 0.5", got)
+	}
+	if Expected(1600, 1500) <= 0.5 {
+		t.Errorf("Expected(1600, 1500) = %v, want the higher rating favoured", Expected(1600, 1500))
+	}
+}
+
+func TestUpdateEqualRatings(t *testing.T) {
+	winner, loser := Update(Default, Default)
+	if math.Abs(winner-(Default+KFactor/2)) > epsilon {
+		t.Errorf("winner = %v, want %v", winner, Default+KFactor/2)
+	}
+	if math.Abs(loser-(Default-KFactor/2)) > epsilon {
+		t.Errorf("loser = %v, want %v", loser, Default-KFactor/2)
+	}
+}
+
+func TestUpdateUpset(t *testing.T) {
+	// A 1400 beating a 1600 was expected to win 1/(1+10^0.5) of the time.
+	winner, loser := Update(1400, 1600)
+	want := KFactor * (1 - 1/(1+math.Sqrt(10)))
+	if math.Abs(winner-1400-want) > epsilon {
+		t.Errorf("winner gained %v, want %v", winner-1400, want)
+	}
+	if math.Abs(1600-loser-want) > epsilon {
+		t.Errorf("loser dropped %v, want %v", 1600-loser, want)
+	}
+	if math.Abs(want-24.31192) > 1e-4 {
+		t.Errorf("upset delta = %v, want about 24.31", want)
+	}
+	if favourite, _ := Update(1600, 1400); favourite-1600 >= want {
+		t.Errorf("favourite gained %v, want less than the upset's %v", favourite-1600, want)
+	}
+}
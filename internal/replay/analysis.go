@@ -0,0 +1,331 @@
+package replay
+
+import (
+	"cmp"
+	"slices"
+
+	"umineko_minesweeper/internal/game"
+	"umineko_minesweeper/internal/solver"
+)
+
+type (
+	ClickKind string
+
+	// Click is one reveal or chord judged by what the player could see when
+	// they made it. Probability is the chance the clicked cell, or the riskiest
+	// cell a chord opened, was a mine; it is -1 when the position was too
+	// complex to work out.
+	Click struct {
+		At          int64     `json:"at"`
+		X           int       `json:"x"`
+		Y           int       `json:"y"`
+		Chord       bool      `json:"chord,omitempty"`
+		Kind        ClickKind `json:"kind"`
+		Probability float64   `json:"probability"`
+		Mine        bool      `json:"mine,omitempty"`
+	}
+
+	// WrongFlag is a flag still standing on a safe cell when the game ended,
+	// judged as the game judges flags. At is when it was placed.
+	WrongFlag struct {
+		At int64 `json:"at"`
+		X  int   `json:"x"`
+		Y  int   `json:"y"`
+	}
+
+	PlayerAnalysis struct {
+		Player           int         `json:"player"`
+		Clicks           []Click     `json:"clicks,omitempty"`
+		Deduced          int         `json:"deduced"`
+		ForcedGuesses    int         `json:"forcedGuesses"`
+		MissedDeductions int         `json:"missedDeductions"`
+		WrongFlags       []WrongFlag `json:"wrongFlags,omitempty"`
+		Fatal            *Click      `json:"fatal,omitempty"`
+	}
+
+	Analysis struct {
+		ReplayID string           `json:"replayId"`
+		Players  []PlayerAnalysis `json:"players"`
+	}
+
+	// view is what one board looked like to the players acting on it.
+	view struct {
+		revealed [][]bool
+		values   [][]int
+		mines    [][]bool
+		flagged  [][]bool
+		placed   [][]placement
+		opened   bool
+		// lives is what the players on this board have left.
+		lives int
+	}
+
+	// placement is who put a flag on a view and when. Planted flags came from
+	// an opponent's ability and are never held against the board's owner.
+	placement struct {
+		player  int
+		at      int64
+		planted bool
+	}
+)
+
+const (
+	// ClickOpening is a first click, which is always safe.
+	ClickOpening ClickKind = "opening"
+	// ClickDeduced is a click on a cell that was certainly safe.
+	ClickDeduced ClickKind = "deduced"
+	// ClickForcedGuess is a gamble taken when no cell was certainly safe.
+	ClickForcedGuess ClickKind = "forced_guess"
+	// ClickMissedDeduction is a gamble taken while a safe cell was available.
+	ClickMissedDeduction ClickKind = "missed_deduction"
+)
+
+// Analyze replays the recorded actions over the mine layout and judges every
+// reveal, chord and flag each player made.
+func Analyze(r *Replay) *Analysis {
+	players := len(r.Characters)
+	a := &Analysis{
+		ReplayID: r.ID,
+		Players:  make([]PlayerAnalysis, players),
+	}
+
+	mines := grid[bool](r.Width, r.Height)
+	for _, c := range r.MineCells {
+		if inBounds(r, c.X, c.Y) {
+			mines[c.Y][c.X] = true
+		}
+	}
+
+	views := make([]*view, players)
+	for p := range views {
+		if p > 0 && r.Mode.SharedBoard() {
+			views[p] = views[0]
+			continue
+		}
+		views[p] = &view{
+			revealed: grid[bool](r.Width, r.Height),
+			values:   grid[int](r.Width, r.Height),
+			mines:    grid[bool](r.Width, r.Height),
+			flagged:  grid[bool](r.Width, r.Height),
+			placed:   grid[placement](r.Width, r.Height),
+			lives:    max(r.Lives, 1),
+		}
+	}
+
+	for _, action := range r.Actions {
+		if action.Player < 0 || action.Player >= players {
+			continue
+		}
+		pa := &a.Players[action.Player]
+		v := views[action.Player]
+
+		switch action.Type {
+		case game.ActionReveal, game.ActionChord:
+			if !inBounds(r, action.X, action.Y) {
+				continue
+			}
+			click := v.judge(r, action)
+			switch click.Kind {
+			case ClickDeduced:
+				pa.Deduced++
+			case ClickForcedGuess:
+				pa.ForcedGuesses++
+			case ClickMissedDeduction:
+				pa.MissedDeductions++
+			}
+			pa.Clicks = append(pa.Clicks, click)
+			if v.fatal(r, action.Cells) {
+				pa.Fatal = &click
+			}
+			v.open(r, action.Cells)
+
+		case game.ActionFlag:
+			if !inBounds(r, action.X, action.Y) {
+				continue
+			}
+			v.flagged[action.Y][action.X] = action.Flagged
+			if action.Flagged {
+				v.placed[action.Y][action.X] = placement{player: action.Player, at: action.At}
+			}
+
+		case game.ActionAbility:
+			switch action.Ability {
+			case game.AbilityRedTruth:
+				v.open(r, action.Cells)
+			case game.AbilityFakeFlag:
+				if action.Target >= 0 && action.Target < players && inBounds(r, action.X, action.Y) {
+					victim := views[action.Target]
+					victim.flagged[action.Y][action.X] = true
+					victim.placed[action.Y][action.X] = placement{player: action.Target, at: action.At, planted: true}
+				}
+			}
+		}
+	}
+
+	// Flags are judged once the game is over, like the game does, so one
+	// taken down again or swept away by a cascade is not held against anyone.
+	judged := make(map[*view]bool, len(views))
+	for _, v := range views {
+		if judged[v] {
+			continue
+		}
+		judged[v] = true
+		for y := range v.flagged {
+			for x, flagged := range v.flagged[y] {
+				f := v.placed[y][x]
+				if flagged && !f.planted && !mines[y][x] {
+					a.Players[f.player].WrongFlags = append(a.Players[f.player].WrongFlags, WrongFlag{At: f.at, X: x, Y: y})
+				}
+			}
+		}
+	}
+
+	for p := range a.Players {
+		pa := &a.Players[p]
+		pa.Player = p
+		slices.SortStableFunc(pa.WrongFlags, func(x, y WrongFlag) int {
+			return cmp.Compare(x.At, y.At)
+		})
+	}
+	return a
+}
+
+// judge classifies a click against the board as it stood just before it.
+func (v *view) judge(r *Replay, action game.Action) Click {
+	click := Click{
+		At:          action.At,
+		X:           action.X,
+		Y:           action.Y,
+		Chord:       action.Type == game.ActionChord,
+		Probability: -1,
+	}
+	for _, c := range action.Cells {
+		if c.Value == game.Mine {
+			click.Mine = true
+		}
+	}
+	if !v.opened {
+		click.Kind = ClickOpening
+		click.Probability = 0
+		return click
+	}
+
+	targets := []solver.Point{{X: action.X, Y: action.Y}}
+	if click.Chord {
+		targets = nil
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				nx, ny := action.X+dx, action.Y+dy
+				if (dx == 0 && dy == 0) || !inBounds(r, nx, ny) {
+					continue
+				}
+				if !v.revealed[ny][nx] && !v.mines[ny][nx] && !v.flagged[ny][nx] {
+					targets = append(targets, solver.Point{X: nx, Y: ny})
+				}
+			}
+		}
+	}
+
+	state := v.state(r)
+	deduced := state.Deduce()
+	safe := make(map[solver.Point]bool, len(deduced.Safe))
+	for _, p := range deduced.Safe {
+		safe[p] = true
+	}
+	certain := true
+	for _, p := range targets {
+		certain = certain && safe[p]
+	}
+	if certain {
+		click.Kind = ClickDeduced
+		click.Probability = 0
+		return click
+	}
+
+	sol, err := state.Solve()
+	if err != nil {
+		click.Kind = ClickForcedGuess
+		if len(deduced.Safe) > 0 {
+			click.Kind = ClickMissedDeduction
+		}
+		return click
+	}
+	click.Probability = 0
+	for _, p := range targets {
+		click.Probability = max(click.Probability, sol.Probability[p.Y][p.X])
+	}
+	switch {
+	case click.Probability == 0:
+		click.Kind = ClickDeduced
+	case len(sol.Safe) > 0:
+		click.Kind = ClickMissedDeduction
+	default:
+		click.Kind = ClickForcedGuess
+	}
+	return click
+}
+
+// fatal takes the lives a click's mines cost off the board and reports
+// whether that ended the game for whoever made it. Turn-based games without
+// MineEndsGame only dock points for a mine.
+func (v *view) fatal(r *Replay, cells []game.Cell) bool {
+	mines := 0
+	for _, c := range cells {
+		if c.Value == game.Mine {
+			mines++
+		}
+	}
+	if mines == 0 {
+		return false
+	}
+	if r.Mode == game.ModeTurns {
+		return r.MineEndsGame
+	}
+	if v.lives <= 0 {
+		return false
+	}
+	v.lives -= mines
+	return v.lives <= 0
+}
+
+func (v *view) open(r *Replay, cells []game.Cell) {
+	for _, c := range cells {
+		if !inBounds(r, c.X, c.Y) {
+			continue
+		}
+		if c.Value == game.Mine {
+			v.mines[c.Y][c.X] = true
+			continue
+		}
+		v.revealed[c.Y][c.X] = true
+		v.values[c.Y][c.X] = int(c.Value)
+		v.flagged[c.Y][c.X] = false
+		v.opened = true
+	}
+}
+
+// state describes the view to the solver. Flags are left out, since judging
+// them is part of the analysis.
+func (v *view) state(r *Replay) *solver.State {
+	state := solver.NewState(r.Width, r.Height, r.Mines)
+	for y := 0; y < r.Height; y++ {
+		for x := 0; x < r.Width; x++ {
+			state.Revealed[y][x] = v.revealed[y][x]
+			state.Values[y][x] = v.values[y][x]
+			state.Known[y][x] = v.mines[y][x]
+		}
+	}
+	return state
+}
+
+func inBounds(r *Replay, x, y int) bool {
+	return x >= 0 && x < r.Width && y >= 0 && y < r.Height
+}
+
+func grid[T any](width, height int) [][]T {
+	g := make([][]T, height)
+	for y := range g {
+		g[y] = make([]T, width)
+	}
+	return g
+}
@@ -0,0 +1,143 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"umineko_minesweeper/internal/game"
+)
+
+const Version = 1
+
+var validID = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+type (
+	Replay struct {
+		Version int       `json:"version"`
+		ID      string    `json:"id"`
+		Code    string    `json:"code"`
+		Mode    game.Mode `json:"mode,omitempty"`
+		Round   int       `json:"round"`
+		Width   int       `json:"width"`
+		Height  int       `json:"height"`
+		Mines   int       `json:"mines"`
+		Seed    uint64    `json:"seed"`
+		NoGuess bool      `json:"noGuess"`
+		Lives   int       `json:"lives,omitempty"`
+		// MineEndsGame is set for turn-based games that a mine ends outright.
+		MineEndsGame bool               `json:"mineEndsGame,omitempty"`
+		TimeLimit    int64              `json:"timeLimit,omitempty"`
+		Scoring      *game.ScoringRules `json:"scoring,omitempty"`
+		Characters   []string           `json:"characters"`
+		MineCells    []game.Cell        `json:"mineCells"`
+		StartedAt    time.Time          `json:"startedAt"`
+		Duration     int64              `json:"duration"`
+		Result       *game.GameResult   `json:"result"`
+		Actions      []game.Action      `json:"actions"`
+	}
+
+	Store struct {
+		dir string
+	}
+)
+
+func FromGame(g *game.Game, characters []string, round int) *Replay {
+	return &Replay{
+		Version:      Version,
+		ID:           fmt.Sprintf("%s-%s-%d", g.StartedAt.UTC().Format("20060102T150405"), g.Code, round),
+		Code:         g.Code,
+		Mode:         g.Mode,
+		Round:        round,
+		Width:        g.Board.Width,
+		Height:       g.Board.Height,
+		Mines:        g.Board.Mines,
+		Seed:         g.Board.Seed,
+		NoGuess:      g.Board.NoGuess,
+		Lives:        g.Lives,
+		MineEndsGame: g.MineEndsGame,
+		TimeLimit:    g.TimeLimit.Milliseconds(),
+		Scoring:      g.Scoring,
+		Characters:   characters,
+		MineCells:    g.Board.GetMinePositions(),
+		StartedAt:    g.StartedAt,
+		Duration:     g.FinishedAt.Sub(g.StartedAt).Milliseconds(),
+		Result:       g.Result,
+		Actions:      g.Actions(),
+	}
+}
+
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+func (s *Store) Path(id string) (string, error) {
+	if !validID.MatchString(id) {
+		return "", fmt.Errorf("invalid replay id")
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+// AnalysisPath is where the analysis of a replay is cached alongside it.
+func (s *Store) AnalysisPath(id string) (string, error) {
+	if !validID.MatchString(id) {
+		return "", fmt.Errorf("invalid replay id")
+	}
+	return filepath.Join(s.dir, id+".analysis.json"), nil
+}
+
+func (s *Store) Save(r *Replay) error {
+	path, err := s.Path(r.ID)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, r)
+}
+
+func (s *Store) SaveAnalysis(a *Analysis) error {
+	path, err := s.AnalysisPath(a.ReplayID)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, a)
+}
+
+func writeFile(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *Store) Load(id string) (*Replay, error) {
+	path, err := s.Path(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("replay not found")
+		}
+		return nil, err
+	}
+	var r Replay
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	if r.Version > Version {
+		return nil, fmt.Errorf("unsupported replay version %d", r.Version)
+	}
+	return &r, nil
+}
@@ -2,12 +2,19 @@ package server
 
 import (
 	"embed"
+	"encoding/json"
 	"io/fs"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/websocket"
 
+	"umineko_minesweeper/internal/game"
+	"umineko_minesweeper/internal/leaderboard"
+	"umineko_minesweeper/internal/replay"
 	"umineko_minesweeper/internal/ws"
 )
 
@@ -32,6 +39,15 @@ func (s *Server) Start(addr string) error {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/ws", s.handleWebSocket)
+	mux.HandleFunc("GET /api/replays/{id}", s.handleReplayDownload)
+	mux.HandleFunc("GET /api/replays/{id}/analysis", s.handleReplayAnalysis)
+	mux.HandleFunc("GET /api/rooms", s.handleListRooms)
+	mux.HandleFunc("POST /api/players", s.handleRegisterPlayer)
+	mux.HandleFunc("GET /api/players/{id}", s.handlePlayerStats)
+	mux.HandleFunc("GET /api/leaderboards/{mode}/{difficulty}", s.handleLeaderboard)
+	mux.HandleFunc("GET /api/leaderboards/daily/{date}/{difficulty}", s.handleDailyLeaderboard)
+	mux.HandleFunc("GET /api/leaderboards/coop/{difficulty}/{players}", s.handleCoopLeaderboard)
+	mux.HandleFunc("GET /api/characters", s.handleListCharacters)
 
 	sub, _ := fs.Sub(s.staticFS, "static")
 	mux.Handle("/", http.FileServer(http.FS(sub)))
@@ -53,3 +69,153 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	go client.WritePump()
 	go client.ReadPump()
 }
+
+func (s *Server) handleReplayDownload(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	path, err := s.hub.Replays.Path(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+id+`.json"`)
+	http.ServeFile(w, r, path)
+}
+
+func (s *Server) handleReplayAnalysis(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	path, err := s.hub.Replays.AnalysisPath(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err := os.Stat(path); err == nil {
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeFile(w, r, path)
+		return
+	}
+
+	// Replays saved before their analysis was cached are worked through once.
+	rec, err := s.hub.Replays.Load(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	analysis := replay.Analyze(rec)
+	if err := s.hub.Replays.SaveAnalysis(analysis); err != nil {
+		log.Printf("failed to save analysis of replay %s: %v", id, err)
+	}
+	writeJSON(w, http.StatusOK, analysis)
+}
+
+func (s *Server) handleListRooms(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.hub.PublicRooms())
+}
+
+func (s *Server) handleRegisterPlayer(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	p, key, err := s.hub.Profiles.Register(req.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]string{
+		"id":   p.ID,
+		"name": p.Name,
+		"key":  key,
+	})
+}
+
+func (s *Server) handlePlayerStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := s.hub.Profiles.Stats(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (s *Server) handleLeaderboard(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+
+	board := leaderboard.Board(r.PathValue("mode"), r.PathValue("difficulty"))
+	writeJSON(w, http.StatusOK, s.hub.Leaderboards.Top(board, limit))
+}
+
+// handleDailyLeaderboard serves a day's challenge results, with "today" naming
+// the current UTC day.
+func (s *Server) handleDailyLeaderboard(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+
+	date := r.PathValue("date")
+	if date == "today" {
+		date = game.DailyDate(time.Now())
+	} else if _, err := time.Parse(time.DateOnly, date); err != nil {
+		http.Error(w, "invalid date", http.StatusBadRequest)
+		return
+	}
+
+	board := leaderboard.Board("daily", date, r.PathValue("difficulty"))
+	writeJSON(w, http.StatusOK, s.hub.Leaderboards.Top(board, limit))
+}
+
+// handleCoopLeaderboard serves the co-op results for one team size.
+func (s *Server) handleCoopLeaderboard(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+
+	players, err := strconv.Atoi(r.PathValue("players"))
+	if err != nil || players < game.MinPlayers || players > game.MaxPlayers {
+		http.Error(w, "invalid team size", http.StatusBadRequest)
+		return
+	}
+
+	board := leaderboard.Board(string(game.ModeCoop), r.PathValue("difficulty"), strconv.Itoa(players))
+	writeJSON(w, http.StatusOK, s.hub.Leaderboards.Top(board, limit))
+}
+
+func parseLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	raw := r.URL.Query().Get("limit")
+	if raw == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		http.Error(w, "invalid limit", http.StatusBadRequest)
+		return 0, false
+	}
+	return n, true
+}
+
+func (s *Server) handleListCharacters(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.hub.Characters.All())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("encode response: %v", err)
+	}
+}
@@ -0,0 +1,431 @@
+package solver
+
+import (
+	"errors"
+	"math"
+)
+
+// maxSearchNodes bounds the backtracking over every frontier component, so a
+// pathological position fails fast instead of stalling its caller.
+const maxSearchNodes = 1 << 22
+
+var (
+	ErrUnknownMineCount = errors.New("solver: total mine count is unknown")
+	ErrInconsistent     = errors.New("solver: no mine layout fits the revealed numbers")
+	ErrTooComplex       = errors.New("solver: position is too complex to enumerate")
+)
+
+type (
+	// Solution is the exact view of a position. Probability holds the chance
+	// each unknown cell is a mine; opened cells are -1 and known mines 1. Safe
+	// and Mines list the unknown cells whose probability is exactly 0 or 1.
+	Solution struct {
+		Safe        []Point
+		Mines       []Point
+		Probability [][]float64
+	}
+
+	// component is a set of frontier cells linked by shared constraints, with
+	// how many of its consistent layouts hold each number of mines and, for
+	// each such count, how many of those layouts mine each cell.
+	component struct {
+		cells   []Point
+		layouts []float64
+		mined   [][]float64
+	}
+
+	rule struct {
+		cells []int
+		mines int
+	}
+)
+
+// Solve works out the exact mine probability of every unknown cell. It
+// enumerates the consistent layouts of each independent frontier component
+// and weights their combinations by the number of ways the remaining mines can
+// be spread over the cells away from the frontier.
+func (s *State) Solve() (*Solution, error) {
+	if s.Mines < 0 {
+		return nil, ErrUnknownMineCount
+	}
+
+	sol := &Solution{Probability: make([][]float64, s.Height)}
+	frontier := make(map[Point]int)
+	var cells []Point
+	var interior []Point
+	remaining := s.Mines
+	for y := 0; y < s.Height; y++ {
+		sol.Probability[y] = make([]float64, s.Width)
+		for x := 0; x < s.Width; x++ {
+			switch {
+			case s.Revealed[y][x]:
+				sol.Probability[y][x] = -1
+			case s.Known[y][x]:
+				sol.Probability[y][x] = 1
+				remaining--
+			}
+		}
+	}
+
+	var rules []rule
+	for _, c := range s.constraints(nil) {
+		r := rule{mines: c.mines}
+		for _, p := range c.cells {
+			i, ok := frontier[p]
+			if !ok {
+				i = len(cells)
+				frontier[p] = i
+				cells = append(cells, p)
+			}
+			r.cells = append(r.cells, i)
+		}
+		if r.mines < 0 || r.mines > len(r.cells) {
+			return nil, ErrInconsistent
+		}
+		rules = append(rules, r)
+	}
+	for y := 0; y < s.Height; y++ {
+		for x := 0; x < s.Width; x++ {
+			p := Point{X: x, Y: y}
+			if _, ok := frontier[p]; !ok && !s.Revealed[y][x] && !s.Known[y][x] {
+				interior = append(interior, p)
+			}
+		}
+	}
+	if remaining < 0 {
+		return nil, ErrInconsistent
+	}
+
+	budget := maxSearchNodes
+	var components []*component
+	for _, group := range split(len(cells), rules) {
+		c, err := enumerate(cells, group, rules, &budget)
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, c)
+	}
+
+	// weight[k] is the log of the number of ways to place the mines left over
+	// once the frontier holds k of them.
+	weight := func(k int) float64 {
+		rest := remaining - k
+		if rest < 0 || rest > len(interior) {
+			return math.Inf(-1)
+		}
+		return logChoose(len(interior), rest)
+	}
+
+	// Distributions over the frontier's mine count are kept as logs, since
+	// the layouts of many components multiply past what a float64 can hold.
+	all := []float64{0}
+	for _, c := range components {
+		all = convolve(all, logCounts(c.layouts))
+	}
+	feasible := false
+	for k, n := range all {
+		if !math.IsInf(n, -1) && !math.IsInf(weight(k), -1) {
+			feasible = true
+		}
+	}
+	if !feasible {
+		return nil, ErrInconsistent
+	}
+
+	// For each component, fold the layout counts of every other component
+	// into a distribution over their combined mine count.
+	for i, c := range components {
+		others := []float64{0}
+		for j, o := range components {
+			if j != i {
+				others = convolve(others, logCounts(o.layouts))
+			}
+		}
+
+		mined := make([]float64, len(c.cells))
+		safe := make([]float64, len(c.cells))
+		for k, w := range logTerms(logCounts(c.layouts), others, weight) {
+			if w == 0 {
+				continue
+			}
+			for ci := range c.cells {
+				mined[ci] += w * c.mined[k][ci] / c.layouts[k]
+				safe[ci] += w * (c.layouts[k] - c.mined[k][ci]) / c.layouts[k]
+			}
+		}
+		for ci, p := range c.cells {
+			sol.place(p, mined[ci], safe[ci])
+		}
+	}
+
+	if len(interior) > 0 {
+		var mined, safe float64
+		for k, w := range logTerms(all, []float64{0}, weight) {
+			rest := float64(remaining - k)
+			mined += w * rest / float64(len(interior))
+			safe += w * (float64(len(interior)) - rest) / float64(len(interior))
+		}
+		for _, p := range interior {
+			sol.place(p, mined, safe)
+		}
+	}
+	return sol, nil
+}
+
+// Safest returns the unknown cells least likely to be mines, or nil when
+// every one left is certainly a mine.
+func (sol *Solution) Safest() []Point {
+	var best []Point
+	lowest := 1.0
+	for y, row := range sol.Probability {
+		for x, p := range row {
+			if math.IsNaN(p) || p < 0 || p >= 1 || p > lowest {
+				continue
+			}
+			if p < lowest {
+				lowest = p
+				best = best[:0]
+			}
+			best = append(best, Point{X: x, Y: y})
+		}
+	}
+	return best
+}
+
+// place records a cell's probability from the weight of the boards where it
+// is a mine and where it is safe, keeping exact zeros exact.
+func (sol *Solution) place(p Point, mined, safe float64) {
+	sol.Probability[p.Y][p.X] = mined / (mined + safe)
+	switch {
+	case mined == 0:
+		sol.Safe = append(sol.Safe, p)
+	case safe == 0:
+		sol.Mines = append(sol.Mines, p)
+	}
+}
+
+// split groups the rules into components that share no cells.
+func split(cells int, rules []rule) [][]int {
+	parent := make([]int, cells)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for _, r := range rules {
+		for _, c := range r.cells[1:] {
+			parent[find(c)] = find(r.cells[0])
+		}
+	}
+
+	index := make(map[int]int)
+	var groups [][]int
+	for i, r := range rules {
+		root := find(r.cells[0])
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
+
+// enumerate counts every layout of a component's cells that satisfies its
+// rules, by backtracking with the rules checked as soon as a cell is set.
+func enumerate(all []Point, group []int, rules []rule, budget *int) (*component, error) {
+	local := make(map[int]int)
+	c := &component{}
+	var touching [][]int
+	for _, ri := range group {
+		for _, cell := range rules[ri].cells {
+			if _, ok := local[cell]; !ok {
+				local[cell] = len(c.cells)
+				c.cells = append(c.cells, all[cell])
+				touching = append(touching, nil)
+			}
+			touching[local[cell]] = append(touching[local[cell]], ri)
+		}
+	}
+
+	placed := make(map[int]int, len(group))
+	open := make(map[int]int, len(group))
+	for _, ri := range group {
+		open[ri] = len(rules[ri].cells)
+	}
+	c.layouts = make([]float64, len(c.cells)+1)
+	c.mined = make([][]float64, len(c.cells)+1)
+	for k := range c.mined {
+		c.mined[k] = make([]float64, len(c.cells))
+	}
+	mine := make([]bool, len(c.cells))
+
+	var search func(i, mines int) error
+	search = func(i, mines int) error {
+		if *budget--; *budget < 0 {
+			return ErrTooComplex
+		}
+		if i == len(c.cells) {
+			c.layouts[mines]++
+			for ci, m := range mine {
+				if m {
+					c.mined[mines][ci]++
+				}
+			}
+			return nil
+		}
+		for _, m := range []bool{false, true} {
+			mine[i] = m
+			ok := true
+			for _, ri := range touching[i] {
+				open[ri]--
+				if m {
+					placed[ri]++
+				}
+				need := rules[ri].mines
+				if placed[ri] > need || placed[ri]+open[ri] < need {
+					ok = false
+				}
+			}
+			var err error
+			if ok {
+				next := mines
+				if m {
+					next++
+				}
+				err = search(i+1, next)
+			}
+			for _, ri := range touching[i] {
+				open[ri]++
+				if m {
+					placed[ri]--
+				}
+			}
+			if err != nil {
+				return err
+			}
+		}
+		mine[i] = false
+		return nil
+	}
+	if err := search(0, 0); err != nil {
+		return nil, err
+	}
+
+	total := 0.0
+	for _, n := range c.layouts {
+		total += n
+	}
+	if total == 0 {
+		return nil, ErrInconsistent
+	}
+	return c, nil
+}
+
+// logTerms combines the log layout counts of a component with the log
+// distribution of the rest of the frontier and the interior weighting,
+// returning for each mine count of the component its share of every consistent
+// board, normalised so the shares sum to 1.
+func logTerms(layouts, others []float64, weight func(int) float64) []float64 {
+	logs := make([]float64, len(layouts))
+	peak := math.Inf(-1)
+	for k, n := range layouts {
+		logs[k] = math.Inf(-1)
+		if math.IsInf(n, -1) {
+			continue
+		}
+		var sum []float64
+		for j, m := range others {
+			if math.IsInf(m, -1) {
+				continue
+			}
+			if w := weight(k + j); !math.IsInf(w, -1) {
+				sum = append(sum, m+w)
+			}
+		}
+		if len(sum) == 0 {
+			continue
+		}
+		logs[k] = n + logSum(sum)
+		peak = max(peak, logs[k])
+	}
+
+	terms := make([]float64, len(layouts))
+	if math.IsInf(peak, -1) {
+		return terms
+	}
+	total := 0.0
+	for k, l := range logs {
+		terms[k] = math.Exp(l - peak)
+		total += terms[k]
+	}
+	for k := range terms {
+		terms[k] /= total
+	}
+	return terms
+}
+
+func logSum(logs []float64) float64 {
+	peak := math.Inf(-1)
+	for _, l := range logs {
+		peak = max(peak, l)
+	}
+	sum := 0.0
+	for _, l := range logs {
+		sum += math.Exp(l - peak)
+	}
+	return peak + math.Log(sum)
+}
+
+// convolve combines two log distributions over mine counts into the log
+// distribution of their sum.
+func convolve(a, b []float64) []float64 {
+	out := make([]float64, len(a)+len(b)-1)
+	for k := range out {
+		out[k] = math.Inf(-1)
+	}
+	for i, x := range a {
+		if math.IsInf(x, -1) {
+			continue
+		}
+		for j, y := range b {
+			if math.IsInf(y, -1) {
+				continue
+			}
+			out[i+j] = logAdd(out[i+j], x+y)
+		}
+	}
+	return out
+}
+
+func logAdd(a, b float64) float64 {
+	if a < b {
+		a, b = b, a
+	}
+	if math.IsInf(b, -1) {
+		return a
+	}
+	return a + math.Log1p(math.Exp(b-a))
+}
+
+func logCounts(counts []float64) []float64 {
+	logs := make([]float64, len(counts))
+	for k, n := range counts {
+		logs[k] = math.Log(n)
+	}
+	return logs
+}
+
+func logChoose(n, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
@@ -0,0 +1,379 @@
+package solver
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+const epsilon = 1e-9
+
+// board builds a state from rows where '?' is an unknown cell, '*' a known
+// mine and a digit an opened cell showing that number.
+func board(mines int, rows ...string) *State {
+	s := NewState(len(rows[0]), len(rows), mines)
+	for y, row := range rows {
+		for x, c := range row {
+			switch {
+			case c == '*':
+				s.Known[y][x] = true
+			case c >= '0' && c <= '8':
+				s.Revealed[y][x] = true
+				s.Values[y][x] = int(c - '0')
+			}
+		}
+	}
+	return s
+}
+
+// probabilities parses rows of space-separated probabilities, with '-' for
+// opened cells.
+func probabilities(rows ...string) [][]float64 {
+	out := make([][]float64, len(rows))
+	for y, row := range rows {
+		for _, f := range strings.Fields(row) {
+			p := -1.0
+			switch f {
+			case "-":
+			case "1/2":
+				p = 0.5
+			case "1/3":
+				p = 1.0 / 3
+			case "1/4":
+				p = 0.25
+			default:
+				p = float64(f[0] - '0')
+			}
+			out[y] = append(out[y], p)
+		}
+	}
+	return out
+}
+
+func TestSolve(t *testing.T) {
+	tests := []struct {
+		name  string
+		state *State
+		want  [][]float64
+	}{
+		{
+			name:  "1-2-1",
+			state: board(2, "???", "121"),
+			want:  probabilities("1 0 1", "- - -"),
+		},
+		{
+			name:  "1-1 against a wall",
+			state: board(1, "???", "11?"),
+			want:  probabilities("1/2 1/2 0", "- - 0"),
+		},
+		{
+			name:  "two independent components",
+			state: board(2, "?1??1?"),
+			want:  probabilities("1/2 - 1/2 1/2 - 1/2"),
+		},
+		{
+			name:  "mine count leaves the interior safe",
+			state: board(1, "?1??"),
+			want:  probabilities("1/2 - 1/2 0"),
+		},
+		{
+			name:  "mine count fills the interior",
+			state: board(2, "?1??"),
+			want:  probabilities("1/2 - 1/2 1"),
+		},
+		{
+			name:  "interior only",
+			state: board(3, "???", "???", "???"),
+			want:  probabilities("1/3 1/3 1/3", "1/3 1/3 1/3", "1/3 1/3 1/3"),
+		},
+		{
+			name:  "known mine taken off the count",
+			state: board(3, "*??", "???", "???"),
+			want:  probabilities("1 1/4 1/4", "1/4 1/4 1/4", "1/4 1/4 1/4"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sol, err := tt.state.Solve()
+			if err != nil {
+				t.Fatalf("Solve() error = %v", err)
+			}
+			for y, row := range tt.want {
+				for x, want := range row {
+					if got := sol.Probability[y][x]; math.Abs(got-want) > epsilon {
+						t.Errorf("probability at (%d, %d) = %v, want %v", x, y, got, want)
+					}
+				}
+			}
+			for _, p := range sol.Safe {
+				if tt.want[p.Y][p.X] != 0 {
+					t.Errorf("%v listed safe with probability %v", p, tt.want[p.Y][p.X])
+				}
+			}
+			for _, p := range sol.Mines {
+				if tt.want[p.Y][p.X] != 1 {
+					t.Errorf("%v listed as a mine with probability %v", p, tt.want[p.Y][p.X])
+				}
+			}
+		})
+	}
+}
+
+func TestSolveErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		state *State
+		want  error
+	}{
+		{"unknown mine count", board(-1, "?1?"), ErrUnknownMineCount},
+		{"number too high for its neighbours", board(2, "?2"), ErrInconsistent},
+		{"numbers disagree", board(2, "?1?", "?3?"), ErrInconsistent},
+		{"too few mines for the numbers", board(0, "?1?"), ErrInconsistent},
+		{"too many mines for the board", board(3, "?1?"), ErrInconsistent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.state.Solve(); !errors.Is(err, tt.want) {
+				t.Errorf("Solve() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestSolveManyComponents spreads enough independent components over the
+// board that their combined layout count would overflow a float64.
+func TestSolveManyComponents(t *testing.T) {
+	const blocks = 14
+	rows := make([]string, 3*blocks)
+	for y := range rows {
+		if y%3 == 1 {
+			rows[y] = strings.Repeat("?4?", blocks)
+		} else {
+			rows[y] = strings.Repeat("???", blocks)
+		}
+	}
+	sol, err := board(4*blocks*blocks, rows...).Solve()
+	if err != nil {
+		t.Fatalf("Solve() error = %v", err)
+	}
+	for y, row := range sol.Probability {
+		for x, p := range row {
+			if y%3 == 1 && x%3 == 1 {
+				continue
+			}
+			if math.Abs(p-0.5) > epsilon {
+				t.Fatalf("probability at (%d, %d) = %v, want 0.5", x, y, p)
+			}
+		}
+	}
+	if safest := sol.Safest(); len(safest) != 8*blocks*blocks {
+		t.Errorf("Safest() returned %d cells, want %d", len(safest), 8*blocks*blocks)
+	}
+}
+
+func TestSafestSkipsNaN(t *testing.T) {
+	sol := &Solution{Probability: [][]float64{{math.NaN(), 0.5, -1, 1}}}
+	safest := sol.Safest()
+	if len(safest) != 1 || safest[0] != (Point{X: 1}) {
+		t.Errorf("Safest() = %v, want [{1 0}]", safest)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name  string
+		cells int
+		rules []rule
+		want  [][]int
+	}{
+		{
+			name:  "shared cell joins rules",
+			cells: 3,
+			rules: []rule{{cells: []int{0, 1}}, {cells: []int{1, 2}}},
+			want:  [][]int{{0, 1}},
+		},
+		{
+			name:  "disjoint rules stay apart",
+			cells: 4,
+			rules: []rule{{cells: []int{0, 1}}, {cells: []int{2, 3}}},
+			want:  [][]int{{0}, {1}},
+		},
+		{
+			name:  "chain through a later rule",
+			cells: 5,
+			rules: []rule{{cells: []int{0, 1}}, {cells: []int{3, 4}}, {cells: []int{1, 2, 3}}},
+			want:  [][]int{{0, 1, 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := split(tt.cells, tt.rules)
+			if !equalGroups(got, tt.want) {
+				t.Errorf("split() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnumerate(t *testing.T) {
+	cells := []Point{{X: 0}, {X: 1}, {X: 2}, {X: 3}}
+	tests := []struct {
+		name    string
+		rules   []rule
+		budget  int
+		layouts []float64
+		mined   [][]float64
+		err     error
+	}{
+		{
+			name:    "one of two",
+			rules:   []rule{{cells: []int{0, 1}, mines: 1}},
+			budget:  maxSearchNodes,
+			layouts: []float64{0, 2, 0},
+			mined:   [][]float64{{0, 0}, {1, 1}, {0, 0}},
+		},
+		{
+			name:    "1-1 overlap",
+			rules:   []rule{{cells: []int{0, 1}, mines: 1}, {cells: []int{0, 1, 2}, mines: 1}},
+			budget:  maxSearchNodes,
+			layouts: []float64{0, 2, 0, 0},
+			mined:   [][]float64{{0, 0, 0}, {1, 1, 0}, {0, 0, 0}, {0, 0, 0}},
+		},
+		{
+			name:    "free mine count",
+			rules:   []rule{{cells: []int{0, 1}, mines: 1}, {cells: []int{1, 2, 3}, mines: 1}},
+			budget:  maxSearchNodes,
+			layouts: []float64{0, 1, 2, 0, 0},
+			mined:   [][]float64{{0, 0, 0, 0}, {0, 1, 0, 0}, {2, 0, 1, 1}, {0, 0, 0, 0}, {0, 0, 0, 0}},
+		},
+		{
+			name:   "no layout fits",
+			rules:  []rule{{cells: []int{0, 1}, mines: 2}, {cells: []int{0, 1, 2}, mines: 1}},
+			budget: maxSearchNodes,
+			err:    ErrInconsistent,
+		},
+		{
+			name:   "out of budget",
+			rules:  []rule{{cells: []int{0, 1, 2, 3}, mines: 2}},
+			budget: 4,
+			err:    ErrTooComplex,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := make([]int, len(tt.rules))
+			for i := range group {
+				group[i] = i
+			}
+			budget := tt.budget
+			c, err := enumerate(cells, group, tt.rules, &budget)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("enumerate() error = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if !equalFloats(c.layouts, tt.layouts) {
+				t.Errorf("layouts = %v, want %v", c.layouts, tt.layouts)
+			}
+			for k := range tt.mined {
+				if !equalFloats(c.mined[k], tt.mined[k]) {
+					t.Errorf("mined[%d] = %v, want %v", k, c.mined[k], tt.mined[k])
+				}
+			}
+		})
+	}
+}
+
+func TestLogTerms(t *testing.T) {
+	inf := math.Inf(-1)
+	flat := func(int) float64 { return 0 }
+	tests := []struct {
+		name    string
+		layouts []float64
+		others  []float64
+		weight  func(int) float64
+		want    []float64
+	}{
+		{
+			name:    "layout counts alone",
+			layouts: []float64{math.Log(1), math.Log(3)},
+			others:  []float64{0},
+			weight:  flat,
+			want:    []float64{0.25, 0.75},
+		},
+		{
+			name:    "weight rules out a mine count",
+			layouts: []float64{0, 0},
+			others:  []float64{0},
+			weight: func(k int) float64 {
+				if k == 1 {
+					return inf
+				}
+				return 0
+			},
+			want: []float64{1, 0},
+		},
+		{
+			name:    "other components shift the total",
+			layouts: []float64{0, 0},
+			others:  []float64{inf, math.Log(2)},
+			weight: func(k int) float64 {
+				return math.Log(float64(k))
+			},
+			want: []float64{1.0 / 3, 2.0 / 3},
+		},
+		{
+			name:    "huge counts stay finite",
+			layouts: []float64{2000, 2000 + math.Log(3)},
+			others:  []float64{1500},
+			weight:  flat,
+			want:    []float64{0.25, 0.75},
+		},
+		{
+			name:    "nothing feasible",
+			layouts: []float64{0},
+			others:  []float64{0},
+			weight:  func(int) float64 { return inf },
+			want:    []float64{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := logTerms(tt.layouts, tt.others, tt.weight)
+			if !equalFloats(got, tt.want) {
+				t.Errorf("logTerms() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func equalFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > epsilon {
+			return false
+		}
+	}
+	return true
+}
+
+func equalGroups(a, b [][]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if a[i][j] != b[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,223 @@
+package solver
+
+type (
+	Point struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+	}
+
+	State struct {
+		Width    int
+		Height   int
+		Mines    int
+		Revealed [][]bool
+		Values   [][]int
+		Known    [][]bool
+	}
+
+	Result struct {
+		Safe  []Point
+		Mines []Point
+	}
+
+	constraint struct {
+		cells []Point
+		mines int
+	}
+)
+
+func NewState(width, height, mines int) *State {
+	s := &State{
+		Width:    width,
+		Height:   height,
+		Mines:    mines,
+		Revealed: make([][]bool, height),
+		Values:   make([][]int, height),
+		Known:    make([][]bool, height),
+	}
+	for y := 0; y < height; y++ {
+		s.Revealed[y] = make([]bool, width)
+		s.Values[y] = make([]int, width)
+		s.Known[y] = make([]bool, width)
+	}
+	return s
+}
+
+func (s *State) InBounds(x, y int) bool {
+	return x >= 0 && x < s.Width && y >= 0 && y < s.Height
+}
+
+func (s *State) Neighbours(x, y int) []Point {
+	var result []Point
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if dy == 0 && dx == 0 {
+				continue
+			}
+			nx, ny := x+dx, y+dy
+			if s.InBounds(nx, ny) {
+				result = append(result, Point{X: nx, Y: ny})
+			}
+		}
+	}
+	return result
+}
+
+// Deduce finds every cell that is certainly safe or certainly a mine using
+// single-cell constraints, subset reasoning between overlapping constraints
+// and the global mine count. Known mines are extended in place.
+func (s *State) Deduce() *Result {
+	result := &Result{}
+	safe := make(map[Point]bool)
+
+	for {
+		constraints := s.constraints(safe)
+		progress := false
+
+		markMine := func(p Point) {
+			if s.Known[p.Y][p.X] {
+				return
+			}
+			s.Known[p.Y][p.X] = true
+			result.Mines = append(result.Mines, p)
+			progress = true
+		}
+		markSafe := func(p Point) {
+			if safe[p] {
+				return
+			}
+			safe[p] = true
+			result.Safe = append(result.Safe, p)
+			progress = true
+		}
+
+		for _, c := range constraints {
+			switch c.mines {
+			case 0:
+				for _, p := range c.cells {
+					markSafe(p)
+				}
+			case len(c.cells):
+				for _, p := range c.cells {
+					markMine(p)
+				}
+			}
+		}
+		if progress {
+			continue
+		}
+
+		touching := make(map[Point][]int)
+		for i, c := range constraints {
+			for _, p := range c.cells {
+				touching[p] = append(touching[p], i)
+			}
+		}
+		for i, a := range constraints {
+			for _, j := range touching[a.cells[0]] {
+				b := constraints[j]
+				if i == j || !subset(a.cells, b.cells) {
+					continue
+				}
+				diff := difference(b.cells, a.cells)
+				mines := b.mines - a.mines
+				switch mines {
+				case 0:
+					for _, p := range diff {
+						markSafe(p)
+					}
+				case len(diff):
+					for _, p := range diff {
+						markMine(p)
+					}
+				}
+			}
+		}
+		if progress {
+			continue
+		}
+
+		if s.Mines >= 0 {
+			var unknown []Point
+			known := 0
+			for y := 0; y < s.Height; y++ {
+				for x := 0; x < s.Width; x++ {
+					p := Point{X: x, Y: y}
+					if s.Known[y][x] {
+						known++
+					} else if !s.Revealed[y][x] && !safe[p] {
+						unknown = append(unknown, p)
+					}
+				}
+			}
+			remaining := s.Mines - known
+			if len(unknown) > 0 && remaining == 0 {
+				for _, p := range unknown {
+					markSafe(p)
+				}
+			} else if len(unknown) > 0 && remaining == len(unknown) {
+				for _, p := range unknown {
+					markMine(p)
+				}
+			}
+		}
+		if !progress {
+			return result
+		}
+	}
+}
+
+func (s *State) constraints(safe map[Point]bool) []constraint {
+	var result []constraint
+	for y := 0; y < s.Height; y++ {
+		for x := 0; x < s.Width; x++ {
+			if !s.Revealed[y][x] {
+				continue
+			}
+			c := constraint{mines: s.Values[y][x]}
+			for _, n := range s.Neighbours(x, y) {
+				if s.Known[n.Y][n.X] {
+					c.mines--
+				} else if !s.Revealed[n.Y][n.X] && !safe[n] {
+					c.cells = append(c.cells, n)
+				}
+			}
+			if len(c.cells) > 0 {
+				result = append(result, c)
+			}
+		}
+	}
+	return result
+}
+
+// subset reports whether a is a strict subset of b.
+func subset(a, b []Point) bool {
+	if len(a) >= len(b) {
+		return false
+	}
+	for _, p := range a {
+		if !contains(b, p) {
+			return false
+		}
+	}
+	return true
+}
+
+func difference(b, a []Point) []Point {
+	var diff []Point
+	for _, p := range b {
+		if !contains(a, p) {
+			diff = append(diff, p)
+		}
+	}
+	return diff
+}
+
+func contains(points []Point, p Point) bool {
+	for _, q := range points {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
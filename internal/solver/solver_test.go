@@ -0,0 +1,124 @@
+package solver
+
+import (
+	"slices"
+	"testing"
+)
+
+// marks parses rows where 'S' is a cell expected to be deduced safe and 'M' one
+// expected to be deduced a mine.
+func marks(rows ...string) (safe, mines []Point) {
+	for y, row := range rows {
+		for x, c := range row {
+			switch c {
+			case 'S':
+				safe = append(safe, Point{X: x, Y: y})
+			case 'M':
+				mines = append(mines, Point{X: x, Y: y})
+			}
+		}
+	}
+	return safe, mines
+}
+
+func TestDeduce(t *testing.T) {
+	tests := []struct {
+		name  string
+		state *State
+		want  []string
+	}{
+		{
+			name:  "number with one neighbour left",
+			state: board(-1, "?1", "11"),
+			want:  []string{"M.", ".."},
+		},
+		{
+			name:  "zero clears its neighbours",
+			state: board(-1, "??", "?0"),
+			want:  []string{"SS", "S."},
+		},
+		{
+			name:  "1-2-1",
+			state: board(-1, "???", "121"),
+			want:  []string{"MSM", "..."},
+		},
+		{
+			name:  "1-1 against a wall",
+			state: board(-1, "???", "11?"),
+			want:  []string{"..S", "..S"},
+		},
+		{
+			name:  "nothing certain",
+			state: board(-1, "?1?"),
+			want:  []string{"..."},
+		},
+		{
+			name:  "mine count spent on a known mine",
+			state: board(1, "*??", "???"),
+			want:  []string{".SS", "SSS"},
+		},
+		{
+			name:  "mine count too loose to decide",
+			state: board(3, "?1?", "???"),
+			want:  []string{"...", "..."},
+		},
+		{
+			name:  "every cell left is a mine",
+			state: board(2, "??"),
+			want:  []string{"MM"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.state.Deduce()
+			safe, mines := marks(tt.want...)
+			if !samePoints(got.Safe, safe) {
+				t.Errorf("Safe = %v, want %v", got.Safe, safe)
+			}
+			if !samePoints(got.Mines, mines) {
+				t.Errorf("Mines = %v, want %v", got.Mines, mines)
+			}
+			for _, p := range got.Mines {
+				if !tt.state.Known[p.Y][p.X] {
+					t.Errorf("mine %v not marked known", p)
+				}
+			}
+		})
+	}
+}
+
+// TestDeduceContradiction checks that numbers no layout satisfies still end
+// the search, without a cell being called both safe and a mine.
+func TestDeduceContradiction(t *testing.T) {
+	tests := []struct {
+		name  string
+		state *State
+	}{
+		{"number with no room for its mines", board(-1, "?3?")},
+		{"numbers that disagree", board(-1, "?1", "?2", "?0")},
+		{"mine count below the numbers", board(0, "?1?")},
+		{"mine count above the board", board(5, "?1?")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.state.Deduce()
+			for _, p := range got.Safe {
+				if slices.Contains(got.Mines, p) {
+					t.Errorf("%v deduced both safe and a mine", p)
+				}
+			}
+		})
+	}
+}
+
+func samePoints(a, b []Point) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, p := range a {
+		if !slices.Contains(b, p) {
+			return false
+		}
+	}
+	return true
+}
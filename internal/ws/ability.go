@@ -0,0 +1,63 @@
+package ws
+
+import (
+	"umineko_minesweeper/internal/game"
+)
+
+func (h *Hub) handleUseAbility(client *Client, target, x, y int) {
+	if client.RoomCode == "" || client.Spectator || client.PendingJoin {
+		return
+	}
+
+	code := client.RoomCode
+	room := h.RoomManager.GetRoom(code)
+	g := h.RoomManager.Game(code)
+	if room == nil || g == nil {
+		return
+	}
+
+	result, err := g.UseAbility(client.PlayerNumber, target, x, y)
+	if err != nil {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	abilities := g.AbilityStates()
+	minesLeft := g.MinesLeft()
+	for _, c := range h.rooms[code] {
+		msg := OutgoingMessage{
+			Type:      MsgAbilityUsed,
+			Player:    result.Player,
+			Target:    result.Target,
+			Ability:   result.Ability,
+			X:         result.X,
+			Y:         result.Y,
+			Abilities: abilities,
+		}
+		if !result.Until.IsZero() {
+			msg.Countdown = int(game.HideDuration.Seconds())
+		}
+		c.SendMessage(msg)
+
+		if result.Ability == game.AbilityFakeFlag {
+			c.SendMessage(OutgoingMessage{
+				Type:      MsgCellFlagged,
+				Player:    result.Target,
+				X:         result.X,
+				Y:         result.Y,
+				Flagged:   true,
+				MinesLeft: minesLeft,
+			})
+		}
+	}
+
+	if result.Reveal != nil {
+		h.publishResults(code, room, g, []*game.RevealResult{result.Reveal})
+	}
+}
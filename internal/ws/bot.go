@@ -0,0 +1,107 @@
+package ws
+
+import (
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"umineko_minesweeper/internal/bot"
+	"umineko_minesweeper/internal/game"
+)
+
+// addBot seats a bot opposite the host and starts the game straight away.
+func (h *Hub) addBot(client *Client, code string, b *bot.Bot) {
+	_, seat, err := h.RoomManager.JoinRoom(code)
+	if err != nil {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: err.Error(),
+		})
+		return
+	}
+	h.RoomManager.SetCharacter(code, seat, h.botCharacter(h.RoomManager.GetHostCharacter(code)))
+
+	log.Printf("%s bot joined room %s as player %d", b.Level, code, seat)
+
+	h.startGame(client, code)
+	go h.runBot(code, seat, b)
+}
+
+// botCharacter picks an enabled character other than the host's, if there is
+// one.
+func (h *Hub) botCharacter(host string) string {
+	var options []string
+	for _, c := range h.Characters.All() {
+		if c.Enabled && c.ID != host {
+			options = append(options, c.ID)
+		}
+	}
+	if len(options) == 0 {
+		return host
+	}
+	return options[rand.IntN(len(options))]
+}
+
+// runBot plays the bot's seat until its room is closed, agreeing to every
+// rematch once a round is over.
+func (h *Hub) runBot(code string, seat int, b *bot.Bot) {
+	rematched := 0
+	for {
+		time.Sleep(b.Delay())
+
+		room := h.RoomManager.GetRoom(code)
+		g := h.RoomManager.Game(code)
+		if room == nil || g == nil {
+			return
+		}
+
+		switch g.GetState() {
+		case game.StatePlaying:
+			h.botMove(code, room, g, seat, b)
+		case game.StateFinished:
+			match, _ := h.RoomManager.GetMatch(code)
+			if match.Round != rematched && !match.Over() {
+				rematched = match.Round
+				if err := h.requestRematch(code, seat); err != nil {
+					log.Printf("bot in room %s failed to request a rematch: %v", code, err)
+				}
+			}
+		}
+	}
+}
+
+func (h *Hub) botMove(code string, room *game.Room, g *game.Game, seat int, b *bot.Bot) {
+	move, ok := b.Next(g, seat)
+	if !ok {
+		return
+	}
+
+	if move.Flag {
+		flagged, err := g.Flag(seat, move.X, move.Y)
+		if err != nil || flagged == nil {
+			return
+		}
+		minesLeft := g.MinesLeft()
+		h.mu.RLock()
+		defer h.mu.RUnlock()
+		for _, c := range h.rooms[code] {
+			c.SendMessage(OutgoingMessage{
+				Type:      MsgCellFlagged,
+				Player:    seat,
+				X:         move.X,
+				Y:         move.Y,
+				Flagged:   *flagged,
+				MinesLeft: minesLeft,
+			})
+		}
+		return
+	}
+
+	results := g.Reveal(seat, move.X, move.Y)
+	if len(results) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.publishResults(code, room, g, results)
+}
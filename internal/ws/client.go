@@ -23,6 +23,9 @@ type Client struct {
 	PlayerNumber int
 	Token        string
 	PendingJoin  bool
+	Spectator    bool
+	ProfileID    string
+	replayStop   chan struct{}
 }
 
 func NewClient(hub *Hub, conn *websocket.Conn) *Client {
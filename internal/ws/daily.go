@@ -0,0 +1,77 @@
+package ws
+
+import (
+	"log"
+	"time"
+
+	"umineko_minesweeper/internal/game"
+)
+
+// handleDailyChallenge starts a solo attempt at today's challenge. The first
+// attempt an identified player makes at each difficulty is ranked; any others
+// are for practice.
+func (h *Hub) handleDailyChallenge(client *Client, difficulty game.Difficulty, character string) {
+	if client.RoomCode != "" {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: "already in a game",
+		})
+		return
+	}
+	if err := h.Characters.Validate(character); err != nil {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	date := game.DailyDate(time.Now())
+	opts, err := game.DailyOptions(date, difficulty, false)
+	if err != nil {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: err.Error(),
+		})
+		return
+	}
+	if client.ProfileID != "" {
+		ranked, err := h.Profiles.StartDaily(client.ProfileID, date, opts.Difficulty)
+		if err != nil {
+			log.Printf("failed to claim daily attempt for player %s: %v", client.ProfileID, err)
+		}
+		opts.Daily.Ranked = ranked
+	}
+
+	room, code, err := h.RoomManager.CreateRoom(opts)
+	if err != nil {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: err.Error(),
+		})
+		return
+	}
+	token := h.seatHost(client, code, character)
+
+	log.Printf("room %s created for the %s daily challenge (difficulty=%s, ranked=%t)", code, date, opts.Difficulty, opts.Daily.Ranked)
+
+	client.SendMessage(OutgoingMessage{
+		Type:  MsgGameCreated,
+		Code:  code,
+		Token: token,
+		Daily: opts.Daily,
+	})
+
+	h.startGame(client, code)
+
+	g := h.RoomManager.Game(code)
+	if g == nil {
+		return
+	}
+	x, y := game.DailyStart(g.Board)
+	if results := g.Reveal(0, x, y); len(results) > 0 {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.publishResults(code, room, g, results)
+	}
+}
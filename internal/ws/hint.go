@@ -0,0 +1,46 @@
+package ws
+
+func (h *Hub) handleHint(client *Client) {
+	if client.RoomCode == "" || client.Spectator || client.PendingJoin {
+		return
+	}
+
+	code := client.RoomCode
+	g := h.RoomManager.Game(code)
+	if g == nil {
+		return
+	}
+
+	hint, err := g.Hint(client.PlayerNumber)
+	if err != nil {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	// Everyone sees that a hint was taken, but only the player who asked for
+	// it learns the cell.
+	msg := OutgoingMessage{
+		Type:      MsgHint,
+		Player:    client.PlayerNumber,
+		Hints:     g.Hints(),
+		Countdown: int(g.HintPenalty.Seconds()),
+	}
+	if scores := g.Scores(); scores != nil {
+		msg.Score = &scores[client.PlayerNumber]
+	}
+	for _, c := range h.rooms[code] {
+		if c == client {
+			mine := msg
+			mine.Hint = hint
+			c.SendMessage(mine)
+		} else {
+			c.SendMessage(msg)
+		}
+	}
+}
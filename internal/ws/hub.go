@@ -4,13 +4,25 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"log"
+	"slices"
 	"strings"
 	"sync"
 	"time"
+	"umineko_minesweeper/internal/bot"
+	"umineko_minesweeper/internal/character"
 	"umineko_minesweeper/internal/game"
+	"umineko_minesweeper/internal/leaderboard"
+	"umineko_minesweeper/internal/profile"
+	"umineko_minesweeper/internal/replay"
 )
 
-const disconnectTimeout = 10 * time.Second
+const (
+	disconnectTimeout = 10 * time.Second
+
+	maxReplaySpeed = 16
+
+	clockInterval = time.Second
+)
 
 type (
 	disconnectTimer struct {
@@ -25,30 +37,53 @@ type (
 		clients          map[*Client]bool
 		rooms            map[string][]*Client
 		disconnectTimers map[string]*disconnectTimer
+		queue            map[*Client]*queueTicket
+		lobby            map[*Client]bool
+		listed           map[string]game.RoomSummary
 		RoomManager      *game.RoomManager
+		Replays          *replay.Store
+		Profiles         *profile.Store
+		Leaderboards     *leaderboard.Store
+		Characters       *character.Registry
 		Register         chan *Client
 		Unregister       chan *Client
 	}
 )
 
-func NewHub(rm *game.RoomManager) *Hub {
+func NewHub(rm *game.RoomManager, replays *replay.Store, profiles *profile.Store, leaderboards *leaderboard.Store, characters *character.Registry) *Hub {
 	return &Hub{
 		clients:          make(map[*Client]bool),
 		rooms:            make(map[string][]*Client),
 		disconnectTimers: make(map[string]*disconnectTimer),
+		queue:            make(map[*Client]*queueTicket),
+		lobby:            make(map[*Client]bool),
+		listed:           make(map[string]game.RoomSummary),
 		RoomManager:      rm,
+		Replays:          replays,
+		Profiles:         profiles,
+		Leaderboards:     leaderboards,
+		Characters:       characters,
 		Register:         make(chan *Client),
 		Unregister:       make(chan *Client),
 	}
 }
 
 func (h *Hub) Run() {
+	matchmaking := time.NewTicker(matchmakingInterval)
+	defer matchmaking.Stop()
+	clock := time.NewTicker(clockInterval)
+	defer clock.Stop()
+
 	for {
 		select {
 		case client := <-h.Register:
 			h.registerClient(client)
 		case client := <-h.Unregister:
 			h.unregisterClient(client)
+		case <-matchmaking.C:
+			h.matchQueue()
+		case <-clock.C:
+			h.tickClocks()
 		}
 	}
 }
@@ -70,6 +105,12 @@ func (h *Hub) unregisterClient(client *Client) {
 	}()
 	if _, ok := h.clients[client]; ok {
 		delete(h.clients, client)
+		delete(h.queue, client)
+		delete(h.lobby, client)
+		if client.replayStop != nil {
+			close(client.replayStop)
+			client.replayStop = nil
+		}
 		close(client.Send)
 		log.Printf("client disconnected (total=%d, room=%s)", len(h.clients), client.RoomCode)
 		h.handleDisconnect(client)
@@ -79,7 +120,9 @@ func (h *Hub) unregisterClient(client *Client) {
 func (h *Hub) HandleMessage(client *Client, msg *IncomingMessage) {
 	switch msg.Type {
 	case MsgCreateGame:
-		h.handleCreateGame(client, msg.Difficulty, msg.Character)
+		h.handleCreateGame(client, msg)
+	case MsgDailyChallenge:
+		h.handleDailyChallenge(client, msg.Difficulty, msg.Character)
 	case MsgJoinGame:
 		h.handleJoinGame(client, msg.Code)
 	case MsgSelectCharacter:
@@ -90,6 +133,32 @@ func (h *Hub) HandleMessage(client *Client, msg *IncomingMessage) {
 		h.handleReveal(client, msg.X, msg.Y)
 	case MsgFlag:
 		h.handleFlag(client, msg.X, msg.Y)
+	case MsgChord:
+		h.handleChord(client, msg.X, msg.Y)
+	case MsgUseAbility:
+		h.handleUseAbility(client, msg.Target, msg.X, msg.Y)
+	case MsgHint:
+		h.handleHint(client)
+	case MsgStartGame:
+		h.handleStartGame(client)
+	case MsgRematch:
+		h.handleRematch(client)
+	case MsgSpectate:
+		h.handleSpectate(client, msg.Code)
+	case MsgReplay:
+		h.handleReplay(client, msg.ReplayID, msg.Speed)
+	case MsgStopReplay:
+		h.handleStopReplay(client)
+	case MsgIdentify:
+		h.handleIdentify(client, msg.Key)
+	case MsgQueueJoin:
+		h.handleQueueJoin(client, msg.Difficulty, msg.Character)
+	case MsgQueueLeave:
+		h.handleQueueLeave(client)
+	case MsgLobbySubscribe:
+		h.handleLobbySubscribe(client)
+	case MsgLobbyUnsubscribe:
+		h.handleLobbyUnsubscribe(client)
 	default:
 		client.SendMessage(OutgoingMessage{
 			Type:    MsgError,
@@ -113,7 +182,7 @@ func generateToken() string {
 	return hex.EncodeToString(b)
 }
 
-func (h *Hub) handleCreateGame(client *Client, difficulty game.Difficulty, character string) {
+func (h *Hub) handleCreateGame(client *Client, msg *IncomingMessage) {
 	if client.RoomCode != "" {
 		client.SendMessage(OutgoingMessage{
 			Type:    MsgError,
@@ -121,8 +190,76 @@ func (h *Hub) handleCreateGame(client *Client, difficulty game.Difficulty, chara
 		})
 		return
 	}
+	if err := h.Characters.Validate(msg.Character); err != nil {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: err.Error(),
+		})
+		return
+	}
+	var b *bot.Bot
+	if msg.Bot != "" {
+		var err error
+		if b, err = bot.New(bot.Level(msg.Bot)); err != nil {
+			client.SendMessage(OutgoingMessage{
+				Type:    MsgError,
+				Message: err.Error(),
+			})
+			return
+		}
+	}
 
-	_, code := h.RoomManager.CreateRoom(difficulty)
+	room, code, err := h.RoomManager.CreateRoom(game.RoomOptions{
+		Mode:         msg.Mode,
+		Difficulty:   msg.Difficulty,
+		Width:        msg.Width,
+		Height:       msg.Height,
+		Mines:        msg.Mines,
+		NoGuess:      msg.NoGuess,
+		Seed:         msg.Seed,
+		BestOf:       msg.BestOf,
+		Public:       msg.Public,
+		MaxPlayers:   msg.Players,
+		Lives:        msg.Lives,
+		MinePenalty:  time.Duration(msg.MinePenalty) * time.Second,
+		TimeLimit:    time.Duration(msg.TimeLimit) * time.Second,
+		Scoring:      msg.Scoring,
+		TurnTime:     time.Duration(msg.TurnTime) * time.Second,
+		MineEndsGame: msg.MineEndsGame,
+		CapFlags:     msg.CapFlags,
+		Abilities:    msg.Abilities,
+		Bot:          msg.Bot,
+		Hints:        msg.Hints,
+		HintPenalty:  time.Duration(msg.HintPenalty) * time.Second,
+	})
+	if err != nil {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: err.Error(),
+		})
+		return
+	}
+	token := h.seatHost(client, code, msg.Character)
+
+	log.Printf("room %s created (mode=%s, difficulty=%s, %dx%d/%d, character=%s, noGuess=%t, public=%t, lives=%d)", code, room.Options.Mode, msg.Difficulty, room.Options.Width, room.Options.Height, room.Options.Mines, msg.Character, msg.NoGuess, msg.Public, room.Options.Lives)
+
+	client.SendMessage(OutgoingMessage{
+		Type:  MsgGameCreated,
+		Code:  code,
+		Token: token,
+	})
+
+	if room.Options.Mode == game.ModeSolo {
+		h.startGame(client, code)
+	}
+	if b != nil {
+		h.addBot(client, code, b)
+	}
+}
+
+// seatHost puts the client who created a room in its first seat and returns
+// their reconnect token.
+func (h *Hub) seatHost(client *Client, code, character string) string {
 	token := generateToken()
 
 	func() {
@@ -136,14 +273,14 @@ func (h *Hub) handleCreateGame(client *Client, difficulty game.Difficulty, chara
 
 	h.RoomManager.SetPlayerToken(code, 0, token)
 	h.RoomManager.SetCharacter(code, 0, character)
+	h.RoomManager.SetProfile(code, 0, client.ProfileID)
 
-	log.Printf("room %s created (difficulty=%s, character=%s)", code, difficulty, character)
-
-	client.SendMessage(OutgoingMessage{
-		Type:  MsgGameCreated,
-		Code:  code,
-		Token: token,
-	})
+	func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.refreshLobby(code)
+	}()
+	return token
 }
 
 func (h *Hub) handleJoinGame(client *Client, code string) {
@@ -165,7 +302,7 @@ func (h *Hub) handleJoinGame(client *Client, code string) {
 		})
 		return
 	}
-	if room.PlayerCount >= 2 {
+	if g := h.RoomManager.Game(code); room.Full() || g == nil || g.GetState() != game.StateWaiting {
 		client.SendMessage(OutgoingMessage{
 			Type:    MsgError,
 			Message: "room is full",
@@ -202,8 +339,24 @@ func (h *Hub) handleSelectCharacter(client *Client, character string) {
 	}
 
 	code := client.RoomCode
+	room := h.RoomManager.GetRoom(code)
+	if room == nil {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: "room not found",
+		})
+		return
+	}
+	if err := h.Characters.Validate(character); err != nil {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: err.Error(),
+		})
+		return
+	}
+
 	hostChar := h.RoomManager.GetHostCharacter(code)
-	if character == hostChar {
+	if room.Options.MaxPlayers == game.MinPlayers && character == hostChar {
 		client.SendMessage(OutgoingMessage{
 			Type:    MsgError,
 			Message: "character already taken",
@@ -211,7 +364,7 @@ func (h *Hub) handleSelectCharacter(client *Client, character string) {
 		return
 	}
 
-	room, err := h.RoomManager.JoinRoom(code)
+	room, seat, err := h.RoomManager.JoinRoom(code)
 	if err != nil {
 		client.SendMessage(OutgoingMessage{
 			Type:    MsgError,
@@ -225,7 +378,7 @@ func (h *Hub) handleSelectCharacter(client *Client, character string) {
 	clients := func() []*Client {
 		h.mu.Lock()
 		defer h.mu.Unlock()
-		client.PlayerNumber = 1
+		client.PlayerNumber = seat
 		client.Token = token
 		client.PendingJoin = false
 		c := make([]*Client, len(h.rooms[code]))
@@ -233,13 +386,19 @@ func (h *Hub) handleSelectCharacter(client *Client, character string) {
 		return c
 	}()
 
-	h.RoomManager.SetPlayerToken(code, 1, token)
-	h.RoomManager.SetCharacter(code, 1, character)
+	h.RoomManager.SetPlayerToken(code, seat, token)
+	h.RoomManager.SetCharacter(code, seat, character)
+	h.RoomManager.SetProfile(code, seat, client.ProfileID)
 
 	for _, c := range clients {
+		if c.Spectator || c.PendingJoin {
+			continue
+		}
 		msg := OutgoingMessage{
 			Type:         MsgPlayerJoined,
 			PlayerNumber: c.PlayerNumber,
+			Player:       seat,
+			Characters:   room.SeatedCharacters(),
 		}
 		if c == client {
 			msg.Token = token
@@ -247,18 +406,56 @@ func (h *Hub) handleSelectCharacter(client *Client, character string) {
 		c.SendMessage(msg)
 	}
 
-	room.Game.Start()
+	log.Printf("player %d joined room %s (%d/%d)", seat, code, room.PlayerCount, room.Options.MaxPlayers)
+
+	if room.Full() {
+		h.startGame(client, code)
+		return
+	}
+
+	func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.refreshLobby(code)
+	}()
+}
 
-	log.Printf("room %s game started (characters: %s vs %s)", code, room.Characters[0], room.Characters[1])
+func (h *Hub) handleStartGame(client *Client) {
+	if client.RoomCode == "" || client.PendingJoin || client.PlayerNumber != 0 {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: "only the host can start the game",
+		})
+		return
+	}
+	h.startGame(client, client.RoomCode)
+}
 
-	for _, c := range clients {
-		c.SendMessage(OutgoingMessage{
-			Type:       MsgGameStart,
-			Width:      room.Game.Board.Width,
-			Height:     room.Game.Board.Height,
-			Mines:      room.Game.Board.Mines,
-			Characters: room.Characters[:],
+func (h *Hub) startGame(client *Client, code string) {
+	room, err := h.RoomManager.StartGame(code)
+	if err != nil {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: err.Error(),
 		})
+		return
+	}
+
+	clients := func() []*Client {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.refreshLobby(code)
+		c := make([]*Client, len(h.rooms[code]))
+		copy(c, h.rooms[code])
+		return c
+	}()
+
+	log.Printf("room %s game started (characters: %s)", code, strings.Join(room.SeatedCharacters(), " vs "))
+
+	g := h.RoomManager.Game(code)
+	match, _ := h.RoomManager.GetMatch(code)
+	for _, c := range clients {
+		c.SendMessage(gameStartMessage(room, g, match))
 	}
 }
 
@@ -280,7 +477,8 @@ func (h *Hub) handleReconnect(client *Client, token string) {
 	}
 
 	room, code, playerNum := h.RoomManager.FindByToken(token)
-	if room == nil {
+	g := h.RoomManager.Game(code)
+	if room == nil || g == nil {
 		client.SendMessage(OutgoingMessage{
 			Type:    MsgError,
 			Message: "session not found",
@@ -289,12 +487,12 @@ func (h *Hub) handleReconnect(client *Client, token string) {
 	}
 
 	timerKey := code + ":" + string(rune('0'+playerNum))
-	bothKey := code + ":both"
+	allKey := code + ":all"
 	clients := func() []*Client {
 		h.mu.Lock()
 		defer h.mu.Unlock()
 		h.cancelTimer(timerKey)
-		h.cancelTimer(bothKey)
+		h.cancelTimer(allKey)
 		client.RoomCode = code
 		client.PlayerNumber = playerNum
 		client.Token = token
@@ -304,18 +502,117 @@ func (h *Hub) handleReconnect(client *Client, token string) {
 		return c
 	}()
 
+	match, _ := h.RoomManager.GetMatch(code)
 	client.SendMessage(OutgoingMessage{
 		Type:         MsgReconnected,
 		Code:         code,
+		Mode:         g.Mode,
 		PlayerNumber: playerNum,
-		Width:        room.Game.Board.Width,
-		Height:       room.Game.Board.Height,
-		Mines:        room.Game.Board.Mines,
-		Characters:   room.Characters[:],
+		Width:        g.Board.Width,
+		Height:       g.Board.Height,
+		Mines:        g.Board.Mines,
+		Characters:   room.SeatedCharacters(),
+		NoGuess:      g.Board.NoGuess,
+		BestOf:       match.BestOf,
+		Round:        match.Round,
+		Scores:       match.Scores,
+		Lives:        g.PlayerLives(),
+		Times:        g.Times(),
+		TimeLimit:    g.TimeLimit.Milliseconds(),
+		Remaining:    g.Remaining().Milliseconds(),
+		Scoring:      g.Scoring,
+		PlayerScores: g.Scores(),
+		Turn:         g.Turn(),
+		Abilities:    g.AbilityStates(),
+		Hints:        g.Hints(),
+		MinesLeft:    g.MinesLeft(),
 	})
 
-	for p := 0; p < 2; p++ {
-		cells := room.Game.GetPlayerCells(p)
+	sendBoards(client, g)
+
+	for _, c := range clients {
+		if c != client {
+			c.SendMessage(OutgoingMessage{
+				Type:   MsgOpponentReconnected,
+				Player: playerNum,
+			})
+		}
+	}
+
+	log.Printf("player %d reconnected to room %s", playerNum, code)
+}
+
+func (h *Hub) handleSpectate(client *Client, code string) {
+	if client.RoomCode != "" {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: "already in a game",
+		})
+		return
+	}
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	room := h.RoomManager.GetRoom(code)
+	g := h.RoomManager.Game(code)
+	if room == nil || g == nil {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: "room not found",
+		})
+		return
+	}
+
+	func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		client.RoomCode = code
+		client.Spectator = true
+		h.rooms[code] = append(h.rooms[code], client)
+		h.refreshLobby(code)
+	}()
+
+	match, _ := h.RoomManager.GetMatch(code)
+	client.SendMessage(OutgoingMessage{
+		Type:         MsgSpectating,
+		Code:         code,
+		Mode:         g.Mode,
+		PlayerNumber: -1,
+		Width:        g.Board.Width,
+		Height:       g.Board.Height,
+		Mines:        g.Board.Mines,
+		Characters:   room.SeatedCharacters(),
+		NoGuess:      g.Board.NoGuess,
+		State:        g.State.String(),
+		BestOf:       match.BestOf,
+		Round:        match.Round,
+		Scores:       match.Scores,
+		Lives:        g.PlayerLives(),
+		Times:        g.Times(),
+		TimeLimit:    g.TimeLimit.Milliseconds(),
+		Remaining:    g.Remaining().Milliseconds(),
+		Scoring:      g.Scoring,
+		PlayerScores: g.Scores(),
+		Turn:         g.Turn(),
+		Abilities:    g.AbilityStates(),
+		MinesLeft:    g.MinesLeft(),
+	})
+
+	sendBoards(client, g)
+
+	log.Printf("spectator joined room %s", code)
+}
+
+// sendBoards replays every player's revealed cells and flags so a client that
+// joins mid-game can rebuild the boards. Co-op and turn-based games share one
+// board, so only the first is sent.
+func sendBoards(client *Client, g *game.Game) {
+	players := len(g.Players)
+	if g.Mode.SharedBoard() {
+		players = 1
+	}
+	for p := range players {
+		cells := g.GetPlayerCells(p)
 		if len(cells) > 0 {
 			client.SendMessage(OutgoingMessage{
 				Type:   MsgCellsRevealed,
@@ -324,7 +621,7 @@ func (h *Hub) handleReconnect(client *Client, token string) {
 			})
 		}
 
-		gameFlags := room.Game.GetPlayerFlags(p)
+		gameFlags := g.GetPlayerFlags(p)
 		for i := 0; i < len(gameFlags); i++ {
 			client.SendMessage(OutgoingMessage{
 				Type:    MsgCellFlagged,
@@ -336,31 +633,29 @@ func (h *Hub) handleReconnect(client *Client, token string) {
 		}
 	}
 
-	for _, c := range clients {
-		if c != client {
-			c.SendMessage(OutgoingMessage{
-				Type: MsgOpponentReconnected,
-			})
-		}
+	for _, p := range g.EliminatedPlayers() {
+		client.SendMessage(OutgoingMessage{
+			Type:   MsgPlayerEliminated,
+			Player: p,
+		})
 	}
-
-	log.Printf("player %d reconnected to room %s", playerNum, code)
 }
 
 func (h *Hub) handleReveal(client *Client, x, y int) {
-	if client.RoomCode == "" {
+	if client.RoomCode == "" || client.Spectator || client.PendingJoin {
 		return
 	}
 
 	code := client.RoomCode
 	room := h.RoomManager.GetRoom(code)
-	if room == nil {
+	g := h.RoomManager.Game(code)
+	if room == nil || g == nil {
 		return
 	}
 
-	results := room.Game.Reveal(client.PlayerNumber, x, y)
+	results := g.Reveal(client.PlayerNumber, x, y)
 	if len(results) == 0 {
-		if !room.Game.Board.IsPlaced() {
+		if !g.IsPlaced() && !g.Mode.SharedBoard() {
 			client.SendMessage(OutgoingMessage{
 				Type: MsgFirstClickPending,
 				X:    x,
@@ -370,59 +665,357 @@ func (h *Hub) handleReveal(client *Client, x, y int) {
 		return
 	}
 
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.publishResults(code, room, g, results)
+}
+
+func (h *Hub) handleChord(client *Client, x, y int) {
+	if client.RoomCode == "" || client.Spectator || client.PendingJoin {
+		return
+	}
+
+	code := client.RoomCode
+	room := h.RoomManager.GetRoom(code)
+	g := h.RoomManager.Game(code)
+	if room == nil || g == nil {
+		return
+	}
+
+	results := g.Chord(client.PlayerNumber, x, y)
+	if len(results) == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.publishResults(code, room, g, results)
+}
+
+// publishResults sends each result to the room, announcing eliminations and
+// finishing the game on the first result that ends it. Callers must hold h.mu
+// and pass the game the results came from.
+func (h *Hub) publishResults(code string, room *game.Room, g *game.Game, results []*game.RevealResult) {
+	scores := g.Scores()
+	abilities := g.AbilityStates()
+	minesLeft := g.MinesLeft()
+	for _, result := range results {
+		var score *game.Score
+		if scores != nil {
+			score = &scores[result.Player]
+		}
+		for _, c := range h.rooms[code] {
+			if result.NoGuessFailed {
+				c.SendMessage(OutgoingMessage{
+					Type:    MsgNoGuessFailed,
+					Message: "no guess-free board could be generated; this one may need guessing",
+				})
+			}
+			if len(result.Cells) > 0 {
+				c.SendMessage(OutgoingMessage{
+					Type:      MsgCellsRevealed,
+					Player:    result.Player,
+					Cells:     result.Cells,
+					Score:     score,
+					Abilities: abilities,
+					MinesLeft: minesLeft,
+				})
+			}
+			if result.LifeLost && !result.Eliminated {
+				c.SendMessage(OutgoingMessage{
+					Type:      MsgLifeLost,
+					Player:    result.Player,
+					Lives:     g.PlayerLives(),
+					Countdown: int(g.MinePenalty.Seconds()),
+					MinesLeft: minesLeft,
+				})
+			}
+			if result.Eliminated && !result.GameOver {
+				c.SendMessage(OutgoingMessage{
+					Type:   MsgPlayerEliminated,
+					Player: result.Player,
+				})
+			}
+		}
+
+		if result.GameOver {
+			var mineCells []game.Cell
+			if result.Result.Reason == game.ReasonMineHit {
+				mineCells = g.Board.GetMinePositions()
+			}
+			h.finishGame(code, room, g, result.Result, mineCells)
+			return
+		}
+	}
+
+	if turn := g.Turn(); turn != nil {
+		h.sendTurn(code, turn)
+	}
+}
+
+// sendTurn tells the room whose turn it is. Callers must hold h.mu.
+func (h *Hub) sendTurn(code string, turn *game.Turn) {
+	for _, c := range h.rooms[code] {
+		c.SendMessage(OutgoingMessage{
+			Type: MsgTurnChanged,
+			Turn: turn,
+		})
+	}
+}
+
+// finishGame announces the end of a round. Once the match is decided the room
+// is torn down; otherwise both players stay seated for a rematch. Callers must
+// hold h.mu.
+func (h *Hub) finishGame(code string, room *game.Room, g *game.Game, result *game.GameResult, mineCells []game.Cell) {
+	match := h.RoomManager.RecordResult(code, result)
+
+	var replayID string
+	var rec *replay.Replay
+	if g.Result == result {
+		rec = replay.FromGame(g, room.SeatedCharacters(), match.Round)
+		replayID = rec.ID
+		h.recordHistory(room, rec)
+		if result.Cleared {
+			h.submitRun(room, rec)
+		}
+	}
+
+	var ratings []int
+	if room.Options.Ranked {
+		ratings = h.applyRatings(room, result)
+	}
+
+	log.Printf("room %s game over (winner=%d, placements=%v, reason=%s, seed=%d, round=%d, scores=%v)", code, result.Winner, result.Placements, result.Reason, g.Board.Seed, match.Round, match.Scores)
+
+	msg := OutgoingMessage{
+		Type:         MsgGameOver,
+		Winner:       result.Winner,
+		Loser:        result.Loser,
+		Cleared:      result.Cleared,
+		Placements:   result.Placements,
+		Times:        result.Times,
+		PlayerScores: result.Scores,
+		Points:       result.Points,
+		Hints:        result.Hints,
+		FlagCounts:   result.Flags,
+		Reason:       string(result.Reason),
+		MineCells:    mineCells,
+		Seed:         g.Board.Seed,
+		BestOf:       match.BestOf,
+		Round:        match.Round,
+		Scores:       match.Scores,
+		MatchOver:    match.Over(),
+		MatchWinner:  match.Winner,
+		ReplayID:     replayID,
+		Ratings:      ratings,
+	}
+
+	clients := h.rooms[code]
+	for _, c := range clients {
+		c.SendMessage(msg)
+	}
+	if rec != nil {
+		go h.saveReplay(slices.Clone(clients), rec)
+	}
+
+	if !match.Over() {
+		return
+	}
+
+	for _, c := range clients {
+		c.RoomCode = ""
+		c.Token = ""
+		c.Spectator = false
+		c.PlayerNumber = -1
+	}
+	h.removeRoom(code)
+}
+
+func (h *Hub) recordHistory(room *game.Room, rec *replay.Replay) {
+	profileIDs := room.ProfileIDs[:room.PlayerCount]
+	characters := room.Characters
+	go func() {
+		for p, id := range profileIDs {
+			if id == "" {
+				continue
+			}
+			record := profile.MatchRecord{
+				ReplayID:  rec.ID,
+				Character: characters[p],
+				Won:       rec.Result.Winner == p || rec.Result.Cleared,
+				Mode:      room.Options.Mode,
+				Placement: slices.Index(rec.Result.Placements, p) + 1,
+				Players:   len(profileIDs),
+				Reason:    rec.Result.Reason,
+				Duration:  rec.Duration,
+				Width:     rec.Width,
+				Height:    rec.Height,
+				Mines:     rec.Mines,
+				PlayedAt:  rec.StartedAt.Add(time.Duration(rec.Duration) * time.Millisecond).UTC(),
+			}
+			if len(profileIDs) == game.MinPlayers && room.Options.Mode != game.ModeCoop {
+				record.OpponentID = profileIDs[1-p]
+				if opponent, ok := h.Profiles.Get(record.OpponentID); ok {
+					record.OpponentName = opponent.Name
+				}
+			}
+			if err := h.Profiles.RecordMatch(id, record); err != nil {
+				log.Printf("failed to record match for player %s: %v", id, err)
+			}
+		}
+	}()
+}
+
+func (h *Hub) handleIdentify(client *Client, key string) {
+	p, err := h.Profiles.Authenticate(key)
+	if err != nil {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		client.ProfileID = p.ID
+	}()
+
+	client.SendMessage(OutgoingMessage{
+		Type:     MsgIdentified,
+		PlayerID: p.ID,
+		Name:     p.Name,
+	})
+}
+
+func (h *Hub) handleRematch(client *Client) {
+	if client.RoomCode == "" || client.Spectator || client.PendingJoin || client.PlayerNumber < 0 {
+		return
+	}
+
+	if err := h.requestRematch(client.RoomCode, client.PlayerNumber); err != nil {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: err.Error(),
+		})
+	}
+}
+
+// requestRematch records a player's vote for another round and starts it once
+// everyone has voted.
+func (h *Hub) requestRematch(code string, player int) error {
+	started, err := h.RoomManager.RequestRematch(code, player)
+	if err != nil {
+		return err
+	}
+
 	clients := func() []*Client {
 		h.mu.RLock()
 		defer h.mu.RUnlock()
-		return h.rooms[code]
+		c := make([]*Client, len(h.rooms[code]))
+		copy(c, h.rooms[code])
+		return c
 	}()
 
-	for _, result := range results {
+	if !started {
 		for _, c := range clients {
 			c.SendMessage(OutgoingMessage{
-				Type:   MsgCellsRevealed,
-				Player: result.Player,
-				Cells:  result.Cells,
+				Type:   MsgRematchRequested,
+				Player: player,
 			})
 		}
+		return nil
+	}
 
-		if result.GameOver {
-			log.Printf("room %s game over (winner=%d, reason=%s)", code, result.Result.Winner, result.Result.Reason)
-			msg := OutgoingMessage{
-				Type:   MsgGameOver,
-				Winner: result.Result.Winner,
-				Loser:  result.Result.Loser,
-				Reason: string(result.Result.Reason),
-			}
-			if result.Result.Reason == game.ReasonMineHit {
-				msg.MineCells = room.Game.Board.GetMinePositions()
-			}
-			for _, c := range clients {
-				c.SendMessage(msg)
-				c.RoomCode = ""
-				c.Token = ""
-			}
-			func() {
-				h.mu.Lock()
-				defer h.mu.Unlock()
-				delete(h.rooms, code)
-				h.RoomManager.RemoveRoom(code)
-			}()
-			return
+	room := h.RoomManager.GetRoom(code)
+	g := h.RoomManager.Game(code)
+	if room == nil || g == nil {
+		return nil
+	}
+	match, _ := h.RoomManager.GetMatch(code)
+
+	log.Printf("room %s round %d started", code, match.Round)
+
+	for _, c := range clients {
+		c.SendMessage(gameStartMessage(room, g, match))
+	}
+	return nil
+}
+
+func gameStartMessage(room *game.Room, g *game.Game, match game.Match) OutgoingMessage {
+	return OutgoingMessage{
+		Type:       MsgGameStart,
+		Mode:       g.Mode,
+		Width:      g.Board.Width,
+		Height:     g.Board.Height,
+		Mines:      g.Board.Mines,
+		Characters: room.SeatedCharacters(),
+		NoGuess:    g.Board.NoGuess,
+		BestOf:     match.BestOf,
+		Round:      match.Round,
+		Scores:     match.Scores,
+		Lives:      g.PlayerLives(),
+		TimeLimit:  g.TimeLimit.Milliseconds(),
+		Scoring:    g.Scoring,
+		Turn:       g.Turn(),
+		Abilities:  g.AbilityStates(),
+		Daily:      room.Options.Daily,
+		Hints:      g.Hints(),
+		MinesLeft:  g.MinesLeft(),
+	}
+}
+
+// tickClocks sends every running game its players' elapsed times and ends any
+// game whose time limit has run out.
+func (h *Hub) tickClocks() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for code, clients := range h.rooms {
+		room := h.RoomManager.GetRoom(code)
+		g := h.RoomManager.Game(code)
+		if room == nil || g == nil || g.GetState() != game.StatePlaying {
+			continue
+		}
+
+		if results := g.Expire(); len(results) > 0 {
+			h.publishResults(code, room, g, results)
+			continue
+		}
+		if g.ExpireTurn() {
+			h.sendTurn(code, g.Turn())
+		}
+
+		msg := OutgoingMessage{
+			Type:      MsgClock,
+			Times:     g.Times(),
+			Remaining: g.Remaining().Milliseconds(),
+		}
+		for _, c := range clients {
+			c.SendMessage(msg)
 		}
 	}
 }
 
 func (h *Hub) handleFlag(client *Client, x, y int) {
-	if client.RoomCode == "" {
+	if client.RoomCode == "" || client.Spectator || client.PendingJoin {
 		return
 	}
 
-	room := h.RoomManager.GetRoom(client.RoomCode)
-	if room == nil {
+	g := h.RoomManager.Game(client.RoomCode)
+	if g == nil {
 		return
 	}
 
-	flagged := room.Game.Flag(client.PlayerNumber, x, y)
+	flagged, err := g.Flag(client.PlayerNumber, x, y)
+	if err != nil {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: err.Error(),
+		})
+		return
+	}
 	if flagged == nil {
 		return
 	}
@@ -433,13 +1026,15 @@ func (h *Hub) handleFlag(client *Client, x, y int) {
 		return h.rooms[client.RoomCode]
 	}()
 
+	minesLeft := g.MinesLeft()
 	for _, c := range clients {
 		c.SendMessage(OutgoingMessage{
-			Type:    MsgCellFlagged,
-			Player:  client.PlayerNumber,
-			X:       x,
-			Y:       y,
-			Flagged: *flagged,
+			Type:      MsgCellFlagged,
+			Player:    client.PlayerNumber,
+			X:         x,
+			Y:         y,
+			Flagged:   *flagged,
+			MinesLeft: minesLeft,
 		})
 	}
 }
@@ -464,56 +1059,80 @@ func (h *Hub) handleDisconnect(client *Client) {
 	h.rooms[code] = remaining
 
 	room := h.RoomManager.GetRoom(code)
-	if room == nil {
+	g := h.RoomManager.Game(code)
+	if room == nil || g == nil {
 		delete(h.rooms, code)
 		return
 	}
 
-	if client.PendingJoin {
+	if client.PendingJoin || client.Spectator {
+		h.refreshLobby(code)
 		return
 	}
 
-	if room.Game.State == game.StateFinished {
-		if len(remaining) == 0 {
-			delete(h.rooms, code)
-			h.RoomManager.RemoveRoom(code)
+	var players []*Client
+	for _, c := range remaining {
+		if !c.Spectator {
+			players = append(players, c)
+		}
+	}
+
+	match, _ := h.RoomManager.GetMatch(code)
+	if g.GetState() == game.StateFinished && match.Over() {
+		if len(players) == 0 {
+			releaseSpectators(remaining, "room closed")
+			h.removeRoom(code)
 			log.Printf("room %s removed (game finished, empty)", code)
 		}
 		return
 	}
 
-	if room.Game.State == game.StateWaiting {
-		for _, c := range remaining {
-			if c.PendingJoin {
+	if g.GetState() == game.StateWaiting {
+		if client.PlayerNumber == 0 {
+			for _, c := range players {
 				c.SendMessage(OutgoingMessage{
 					Type:    MsgError,
 					Message: "host left the room",
 				})
 				c.RoomCode = ""
+				c.Token = ""
 				c.PendingJoin = false
+				c.PlayerNumber = -1
 			}
+			releaseSpectators(remaining, "host left the room")
+			h.removeRoom(code)
+			log.Printf("room %s removed (waiting, host left)", code)
+			return
 		}
-		hasRealPlayer := false
-		for _, c := range remaining {
-			if !c.PendingJoin {
-				hasRealPlayer = true
-				break
+
+		h.RoomManager.LeaveRoom(code, client.PlayerNumber)
+		for _, c := range players {
+			if !c.PendingJoin && c.PlayerNumber > client.PlayerNumber {
+				c.PlayerNumber--
 			}
 		}
-		if !hasRealPlayer {
-			delete(h.rooms, code)
-			h.RoomManager.RemoveRoom(code)
-			log.Printf("room %s removed (waiting, host left)", code)
+		for _, c := range remaining {
+			if c.PendingJoin {
+				continue
+			}
+			c.SendMessage(OutgoingMessage{
+				Type:         MsgPlayerLeft,
+				PlayerNumber: c.PlayerNumber,
+				Player:       client.PlayerNumber,
+				Characters:   room.SeatedCharacters(),
+			})
 		}
+		h.refreshLobby(code)
+		log.Printf("player %d left room %s before the start", client.PlayerNumber, code)
 		return
 	}
 
-	if len(remaining) == 0 {
-		otherPlayer := 1 - client.PlayerNumber
-		existingKey := code + ":" + string(rune('0'+otherPlayer))
-		h.cancelTimer(existingKey)
+	if len(players) == 0 {
+		for p := range g.Players {
+			h.cancelTimer(code + ":" + string(rune('0'+p)))
+		}
 
-		timerKey := code + ":both"
+		timerKey := code + ":all"
 		cancelChan := make(chan struct{})
 		timer := time.AfterFunc(disconnectTimeout, func() {
 			h.mu.Lock()
@@ -526,9 +1145,9 @@ func (h *Hub) handleDisconnect(client *Client) {
 			}
 
 			delete(h.disconnectTimers, timerKey)
-			delete(h.rooms, code)
-			h.RoomManager.RemoveRoom(code)
-			log.Printf("room %s removed (both players disconnected)", code)
+			releaseSpectators(h.rooms[code], "room closed")
+			h.removeRoom(code)
+			log.Printf("room %s removed (all players disconnected)", code)
 		})
 		h.disconnectTimers[timerKey] = &disconnectTimer{
 			timer:      timer,
@@ -536,7 +1155,7 @@ func (h *Hub) handleDisconnect(client *Client) {
 			roomCode:   code,
 			cancelChan: cancelChan,
 		}
-		log.Printf("both players disconnected from room %s, waiting %v", code, disconnectTimeout)
+		log.Printf("all players disconnected from room %s, waiting %v", code, disconnectTimeout)
 		return
 	}
 
@@ -546,6 +1165,7 @@ func (h *Hub) handleDisconnect(client *Client) {
 	for _, c := range remaining {
 		c.SendMessage(OutgoingMessage{
 			Type:      MsgOpponentDisconnected,
+			Player:    client.PlayerNumber,
 			Countdown: int(disconnectTimeout.Seconds()),
 		})
 	}
@@ -563,29 +1183,17 @@ func (h *Hub) handleDisconnect(client *Client) {
 		}
 
 		currentRoom := h.RoomManager.GetRoom(code)
-		if currentRoom == nil {
+		currentGame := h.RoomManager.Game(code)
+		if currentRoom == nil || currentGame == nil {
 			return
 		}
 
-		result := currentRoom.Game.Forfeit(client.PlayerNumber)
-		if result == nil {
+		results := h.RoomManager.Forfeit(code, client.PlayerNumber)
+		if len(results) == 0 {
 			return
 		}
 
-		if currentClients, ok := h.rooms[code]; ok {
-			for _, c := range currentClients {
-				c.SendMessage(OutgoingMessage{
-					Type:   MsgGameOver,
-					Winner: result.Winner,
-					Loser:  result.Loser,
-					Reason: string(result.Reason),
-				})
-				c.RoomCode = ""
-				c.Token = ""
-			}
-		}
-		delete(h.rooms, code)
-		h.RoomManager.RemoveRoom(code)
+		h.publishResults(code, currentRoom, currentGame, results)
 
 		log.Printf("player %d forfeited room %s (disconnect timeout)", client.PlayerNumber, code)
 	})
@@ -599,3 +1207,18 @@ func (h *Hub) handleDisconnect(client *Client) {
 
 	log.Printf("player %d disconnected from room %s, waiting %v for reconnect", client.PlayerNumber, code, disconnectTimeout)
 }
+
+func releaseSpectators(clients []*Client, reason string) {
+	for _, c := range clients {
+		if !c.Spectator {
+			continue
+		}
+		c.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: reason,
+		})
+		c.RoomCode = ""
+		c.Spectator = false
+		c.PlayerNumber = -1
+	}
+}
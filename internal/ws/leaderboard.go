@@ -0,0 +1,77 @@
+package ws
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"umineko_minesweeper/internal/game"
+	"umineko_minesweeper/internal/leaderboard"
+	"umineko_minesweeper/internal/replay"
+)
+
+// runBoard names the leaderboard a cleared run counts towards. Only preset
+// difficulties with the standard rules and a fresh board are ranked, so every
+// run on a board is comparable.
+func runBoard(room *game.Room) (string, bool) {
+	opts := room.Options
+	if opts.Daily != nil {
+		if !opts.Daily.Ranked {
+			return "", false
+		}
+		return leaderboard.Board("daily", opts.Daily.Date, string(opts.Difficulty)), true
+	}
+	if opts.Difficulty == game.Custom || opts.NoGuess || opts.Lives > 1 || opts.Seed != 0 {
+		return "", false
+	}
+	difficulty := opts.Difficulty
+	if difficulty == "" {
+		difficulty = game.Medium
+	}
+	// Co-op teams are only ranked against teams of the same size.
+	if opts.Mode == game.ModeCoop {
+		return leaderboard.Board(string(opts.Mode), string(difficulty), strconv.Itoa(room.PlayerCount)), true
+	}
+	return leaderboard.Board(string(opts.Mode), string(difficulty)), true
+}
+
+func (h *Hub) submitRun(room *game.Room, rec *replay.Replay) {
+	board, ok := runBoard(room)
+	if !ok {
+		return
+	}
+	// A run that leaned on hints is not ranked alongside ones that did not.
+	for _, n := range rec.Result.Hints {
+		if n > 0 {
+			return
+		}
+	}
+
+	entry := leaderboard.Entry{
+		Time:     rec.Duration,
+		ReplayID: rec.ID,
+		PlayedAt: rec.StartedAt.Add(time.Duration(rec.Duration) * time.Millisecond).UTC(),
+	}
+	for p := 0; p < room.PlayerCount; p++ {
+		entry.Members = append(entry.Members, leaderboard.Member{
+			ProfileID: room.ProfileIDs[p],
+			Character: room.Characters[p],
+		})
+	}
+
+	go func() {
+		for i, m := range entry.Members {
+			if p, ok := h.Profiles.Get(m.ProfileID); ok {
+				entry.Members[i].Name = p.Name
+			}
+		}
+		rank, err := h.Leaderboards.Submit(board, entry)
+		if err != nil {
+			log.Printf("failed to submit run %s to %s: %v", rec.ID, board, err)
+			return
+		}
+		if rank > 0 {
+			log.Printf("run %s placed #%d on %s", rec.ID, rank, board)
+		}
+	}()
+}
@@ -0,0 +1,96 @@
+package ws
+
+import (
+	"log"
+	"time"
+
+	"umineko_minesweeper/internal/game"
+)
+
+// removeRoom drops a room from both the hub and the room manager. Callers must
+// hold h.mu.
+func (h *Hub) removeRoom(code string) {
+	delete(h.rooms, code)
+	h.RoomManager.RemoveRoom(code)
+	h.refreshLobby(code)
+}
+
+func (h *Hub) handleLobbySubscribe(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lobby[client] = true
+	client.SendMessage(OutgoingMessage{
+		Type:  MsgLobbyRooms,
+		Rooms: h.publicRooms(),
+	})
+	log.Printf("lobby subscriber added (total=%d)", len(h.lobby))
+}
+
+func (h *Hub) handleLobbyUnsubscribe(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.lobby, client)
+}
+
+func (h *Hub) PublicRooms() []game.RoomSummary {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.publicRooms()
+}
+
+// publicRooms lists open public rooms with live spectator counts. Callers must
+// hold h.mu.
+func (h *Hub) publicRooms() []game.RoomSummary {
+	rooms := h.RoomManager.OpenPublicRooms()
+	for i := range rooms {
+		rooms[i].Spectators = h.spectatorCount(rooms[i].Code)
+		rooms[i].Age = int64(time.Since(rooms[i].CreatedAt).Seconds())
+	}
+	return rooms
+}
+
+func (h *Hub) spectatorCount(code string) int {
+	count := 0
+	for _, c := range h.rooms[code] {
+		if c.Spectator {
+			count++
+		}
+	}
+	return count
+}
+
+// refreshLobby compares a room's current listing with what subscribers were
+// last told and broadcasts the difference. Callers must hold h.mu.
+func (h *Hub) refreshLobby(code string) {
+	summary, open := h.RoomManager.OpenPublicRoom(code)
+	if open {
+		summary.Spectators = h.spectatorCount(code)
+	}
+	previous, listed := h.listed[code]
+
+	listing := summary
+	listing.Age = int64(time.Since(summary.CreatedAt).Seconds())
+
+	var msg OutgoingMessage
+	switch {
+	case open && !listed:
+		msg = OutgoingMessage{Type: MsgRoomAdded, Room: &listing}
+	case open && listed && previous != summary:
+		msg = OutgoingMessage{Type: MsgRoomUpdated, Room: &listing}
+	case !open && listed:
+		msg = OutgoingMessage{Type: MsgRoomRemoved, Code: code}
+	default:
+		return
+	}
+
+	if open {
+		h.listed[code] = summary
+	} else {
+		delete(h.listed, code)
+	}
+
+	for c := range h.lobby {
+		c.SendMessage(msg)
+	}
+}
@@ -1,18 +1,45 @@
 package ws
 
-import "umineko_minesweeper/internal/game"
+import (
+	"umineko_minesweeper/internal/game"
+	"umineko_minesweeper/internal/replay"
+)
 
 type (
 	MessageType string
 
 	IncomingMessage struct {
-		Type       MessageType     `json:"type"`
-		Code       string          `json:"code,omitempty"`
-		Token      string          `json:"token,omitempty"`
-		Difficulty game.Difficulty `json:"difficulty,omitempty"`
-		Character  string          `json:"character,omitempty"`
-		X          int             `json:"x"`
-		Y          int             `json:"y"`
+		Type         MessageType        `json:"type"`
+		Mode         game.Mode          `json:"mode,omitempty"`
+		Code         string             `json:"code,omitempty"`
+		Token        string             `json:"token,omitempty"`
+		Difficulty   game.Difficulty    `json:"difficulty,omitempty"`
+		Character    string             `json:"character,omitempty"`
+		Width        int                `json:"width,omitempty"`
+		Height       int                `json:"height,omitempty"`
+		Mines        int                `json:"mines,omitempty"`
+		NoGuess      bool               `json:"noGuess,omitempty"`
+		Seed         uint64             `json:"seed,omitempty"`
+		BestOf       int                `json:"bestOf,omitempty"`
+		ReplayID     string             `json:"replayId,omitempty"`
+		Speed        float64            `json:"speed,omitempty"`
+		Key          string             `json:"key,omitempty"`
+		Public       bool               `json:"public,omitempty"`
+		Players      int                `json:"players,omitempty"`
+		Lives        int                `json:"lives,omitempty"`
+		MinePenalty  int                `json:"minePenalty,omitempty"`
+		TimeLimit    int                `json:"timeLimit,omitempty"`
+		Scoring      *game.ScoringRules `json:"scoring,omitempty"`
+		TurnTime     int                `json:"turnTime,omitempty"`
+		MineEndsGame bool               `json:"mineEndsGame,omitempty"`
+		CapFlags     bool               `json:"capFlags,omitempty"`
+		Abilities    bool               `json:"abilities,omitempty"`
+		Target       int                `json:"target,omitempty"`
+		Bot          string             `json:"bot,omitempty"`
+		Hints        int                `json:"hints,omitempty"`
+		HintPenalty  int                `json:"hintPenalty,omitempty"`
+		X            int                `json:"x"`
+		Y            int                `json:"y"`
 	}
 
 	FlagData struct {
@@ -21,47 +48,118 @@ type (
 	}
 
 	OutgoingMessage struct {
-		Type          MessageType `json:"type"`
-		Code          string      `json:"code,omitempty"`
-		Token         string      `json:"token,omitempty"`
-		PlayerNumber  int         `json:"playerNumber"`
-		Width         int         `json:"width"`
-		Height        int         `json:"height"`
-		Mines         int         `json:"mines"`
-		Player        int         `json:"player"`
-		Cells         []game.Cell `json:"cells,omitempty"`
-		Flags         []FlagData  `json:"flags,omitempty"`
-		X             int         `json:"x"`
-		Y             int         `json:"y"`
-		Flagged       bool        `json:"flagged"`
-		Winner        int         `json:"winner"`
-		Loser         int         `json:"loser"`
-		Reason        string      `json:"reason,omitempty"`
-		Message       string      `json:"message,omitempty"`
-		Countdown     int         `json:"countdown"`
-		MineCells     []game.Cell `json:"mineCells,omitempty"`
-		Characters    []string    `json:"characters,omitempty"`
-		HostCharacter string      `json:"hostCharacter,omitempty"`
+		Type          MessageType         `json:"type"`
+		Code          string              `json:"code,omitempty"`
+		Token         string              `json:"token,omitempty"`
+		PlayerNumber  int                 `json:"playerNumber"`
+		Width         int                 `json:"width"`
+		Height        int                 `json:"height"`
+		Mines         int                 `json:"mines"`
+		Player        int                 `json:"player"`
+		Cells         []game.Cell         `json:"cells,omitempty"`
+		Flags         []FlagData          `json:"flags,omitempty"`
+		X             int                 `json:"x"`
+		Y             int                 `json:"y"`
+		Flagged       bool                `json:"flagged"`
+		Winner        int                 `json:"winner"`
+		Loser         int                 `json:"loser"`
+		Cleared       bool                `json:"cleared,omitempty"`
+		Placements    []int               `json:"placements,omitempty"`
+		Reason        string              `json:"reason,omitempty"`
+		Message       string              `json:"message,omitempty"`
+		Countdown     int                 `json:"countdown"`
+		MineCells     []game.Cell         `json:"mineCells,omitempty"`
+		Characters    []string            `json:"characters,omitempty"`
+		HostCharacter string              `json:"hostCharacter,omitempty"`
+		NoGuess       bool                `json:"noGuess,omitempty"`
+		State         string              `json:"state,omitempty"`
+		Seed          uint64              `json:"seed,omitempty"`
+		BestOf        int                 `json:"bestOf,omitempty"`
+		Round         int                 `json:"round,omitempty"`
+		Scores        []int               `json:"scores,omitempty"`
+		MatchOver     bool                `json:"matchOver"`
+		MatchWinner   int                 `json:"matchWinner"`
+		ReplayID      string              `json:"replayId,omitempty"`
+		Speed         float64             `json:"speed,omitempty"`
+		PlayerID      string              `json:"playerId,omitempty"`
+		Name          string              `json:"name,omitempty"`
+		Ratings       []int               `json:"ratings,omitempty"`
+		Lives         []int               `json:"lives,omitempty"`
+		Times         []int64             `json:"times,omitempty"`
+		TimeLimit     int64               `json:"timeLimit,omitempty"`
+		Remaining     int64               `json:"remaining,omitempty"`
+		Scoring       *game.ScoringRules  `json:"scoring,omitempty"`
+		Score         *game.Score         `json:"score,omitempty"`
+		PlayerScores  []game.Score        `json:"playerScores,omitempty"`
+		Turn          *game.Turn          `json:"turn,omitempty"`
+		Points        []int               `json:"points,omitempty"`
+		Ability       game.Ability        `json:"ability,omitempty"`
+		Target        int                 `json:"target"`
+		Abilities     []game.AbilityState `json:"abilities,omitempty"`
+		Daily         *game.Daily         `json:"daily,omitempty"`
+		Hint          *game.Hint          `json:"hint,omitempty"`
+		Hints         []int               `json:"hints,omitempty"`
+		MinesLeft     []int               `json:"minesLeft,omitempty"`
+		FlagCounts    []game.FlagCount    `json:"flagCounts,omitempty"`
+		Analysis      *replay.Analysis    `json:"analysis,omitempty"`
+		Difficulty    game.Difficulty     `json:"difficulty,omitempty"`
+		Mode          game.Mode           `json:"mode,omitempty"`
+		Room          *game.RoomSummary   `json:"room,omitempty"`
+		Rooms         []game.RoomSummary  `json:"rooms,omitempty"`
 	}
 )
 
 const (
 	MsgCreateGame           MessageType = "create_game"
+	MsgDailyChallenge       MessageType = "daily_challenge"
 	MsgJoinGame             MessageType = "join_game"
 	MsgReconnect            MessageType = "reconnect"
 	MsgReveal               MessageType = "reveal"
 	MsgFlag                 MessageType = "flag"
+	MsgChord                MessageType = "chord"
+	MsgUseAbility           MessageType = "use_ability"
+	MsgHint                 MessageType = "hint"
+	MsgStartGame            MessageType = "start_game"
+	MsgRematch              MessageType = "rematch"
+	MsgSpectate             MessageType = "spectate"
+	MsgReplay               MessageType = "replay"
+	MsgStopReplay           MessageType = "stop_replay"
+	MsgIdentify             MessageType = "identify"
+	MsgQueueJoin            MessageType = "queue_join"
+	MsgQueueLeave           MessageType = "queue_leave"
+	MsgLobbySubscribe       MessageType = "lobby_subscribe"
+	MsgLobbyUnsubscribe     MessageType = "lobby_unsubscribe"
 	MsgJoinPending          MessageType = "join_pending"
 	MsgSelectCharacter      MessageType = "select_character"
 	MsgGameCreated          MessageType = "game_created"
 	MsgPlayerJoined         MessageType = "player_joined"
+	MsgPlayerLeft           MessageType = "player_left"
 	MsgGameStart            MessageType = "game_start"
 	MsgCellsRevealed        MessageType = "cells_revealed"
 	MsgCellFlagged          MessageType = "cell_flagged"
 	MsgGameOver             MessageType = "game_over"
+	MsgGameAnalysis         MessageType = "game_analysis"
 	MsgOpponentDisconnected MessageType = "opponent_disconnected"
 	MsgOpponentReconnected  MessageType = "opponent_reconnected"
 	MsgReconnected          MessageType = "reconnected"
+	MsgLifeLost             MessageType = "life_lost"
+	MsgClock                MessageType = "clock"
+	MsgTurnChanged          MessageType = "turn_changed"
+	MsgPlayerEliminated     MessageType = "player_eliminated"
+	MsgAbilityUsed          MessageType = "ability_used"
 	MsgFirstClickPending    MessageType = "first_click_pending"
+	MsgNoGuessFailed        MessageType = "no_guess_failed"
+	MsgRematchRequested     MessageType = "rematch_requested"
+	MsgSpectating           MessageType = "spectating"
+	MsgReplayStart          MessageType = "replay_start"
+	MsgReplayEnd            MessageType = "replay_end"
+	MsgIdentified           MessageType = "identified"
+	MsgQueued               MessageType = "queued"
+	MsgQueueLeft            MessageType = "queue_left"
+	MsgMatchFound           MessageType = "match_found"
+	MsgLobbyRooms           MessageType = "lobby_rooms"
+	MsgRoomAdded            MessageType = "room_added"
+	MsgRoomUpdated          MessageType = "room_updated"
+	MsgRoomRemoved          MessageType = "room_removed"
 	MsgError                MessageType = "error"
 )
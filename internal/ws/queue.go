@@ -0,0 +1,238 @@
+package ws
+
+import (
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"umineko_minesweeper/internal/game"
+)
+
+const (
+	matchmakingInterval = time.Second
+
+	baseRatingWindow  = 100.0
+	ratingWindowGrow  = 50.0
+	ratingWindowEvery = 5 * time.Second
+	maxRatingWindow   = 600.0
+)
+
+type queueTicket struct {
+	client     *Client
+	profileID  string
+	rating     float64
+	difficulty game.Difficulty
+	character  string
+	joinedAt   time.Time
+}
+
+// window is how far apart two ratings may be for this ticket to accept a
+// match. It widens the longer the player has been waiting.
+func (t *queueTicket) window(now time.Time) float64 {
+	steps := float64(now.Sub(t.joinedAt) / ratingWindowEvery)
+	return math.Min(baseRatingWindow+steps*ratingWindowGrow, maxRatingWindow)
+}
+
+func (t *queueTicket) compatible(other *queueTicket, now time.Time) bool {
+	if t.difficulty != other.difficulty || t.character == other.character || t.profileID == other.profileID {
+		return false
+	}
+	window := math.Max(t.window(now), other.window(now))
+	return math.Abs(t.rating-other.rating) <= window
+}
+
+func (h *Hub) handleQueueJoin(client *Client, difficulty game.Difficulty, character string) {
+	if client.RoomCode != "" {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: "already in a game",
+		})
+		return
+	}
+	if client.ProfileID == "" {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: "identify before joining the ranked queue",
+		})
+		return
+	}
+	if difficulty == "" {
+		difficulty = game.Medium
+	}
+	if _, _, _, err := game.GetDifficultyConfig(difficulty); err != nil {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: err.Error(),
+		})
+		return
+	}
+	if err := h.Characters.Validate(character); err != nil {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	p, ok := h.Profiles.Get(client.ProfileID)
+	if !ok {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: "player not found",
+		})
+		return
+	}
+
+	func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.queue[client] = &queueTicket{
+			client:     client,
+			profileID:  p.ID,
+			rating:     p.Rating,
+			difficulty: difficulty,
+			character:  character,
+			joinedAt:   time.Now(),
+		}
+	}()
+
+	log.Printf("player %s queued for ranked %s (rating=%.0f)", p.ID, difficulty, p.Rating)
+
+	client.SendMessage(OutgoingMessage{
+		Type:       MsgQueued,
+		Difficulty: difficulty,
+	})
+}
+
+func (h *Hub) handleQueueLeave(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.queue[client]; !ok {
+		return
+	}
+	delete(h.queue, client)
+	client.SendMessage(OutgoingMessage{
+		Type: MsgQueueLeft,
+	})
+}
+
+// matchQueue pairs waiting tickets, oldest first, with the closest-rated
+// compatible opponent.
+func (h *Hub) matchQueue() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	var tickets []*queueTicket
+	for client, t := range h.queue {
+		if client.RoomCode != "" {
+			delete(h.queue, client)
+			continue
+		}
+		tickets = append(tickets, t)
+	}
+	sort.Slice(tickets, func(i, j int) bool {
+		return tickets[i].joinedAt.Before(tickets[j].joinedAt)
+	})
+
+	matched := make(map[*queueTicket]bool)
+	for i, a := range tickets {
+		if matched[a] {
+			continue
+		}
+		var best *queueTicket
+		for _, b := range tickets[i+1:] {
+			if matched[b] || !a.compatible(b, now) {
+				continue
+			}
+			if best == nil || math.Abs(a.rating-b.rating) < math.Abs(a.rating-best.rating) {
+				best = b
+			}
+		}
+		if best == nil {
+			continue
+		}
+		matched[a] = true
+		matched[best] = true
+		delete(h.queue, a.client)
+		delete(h.queue, best.client)
+		h.startRankedRoom(a, best)
+	}
+}
+
+// startRankedRoom seats two matched tickets in a fresh ranked room. Callers
+// must hold h.mu.
+func (h *Hub) startRankedRoom(a, b *queueTicket) {
+	room, code, err := h.RoomManager.CreateRoom(game.RoomOptions{
+		Difficulty: a.difficulty,
+		Ranked:     true,
+	})
+	if err != nil {
+		log.Printf("failed to create ranked room: %v", err)
+		return
+	}
+	if _, _, err := h.RoomManager.JoinRoom(code); err != nil {
+		log.Printf("failed to seat ranked room %s: %v", code, err)
+		return
+	}
+
+	tickets := []*queueTicket{a, b}
+	for p, t := range tickets {
+		token := generateToken()
+		t.client.RoomCode = code
+		t.client.PlayerNumber = p
+		t.client.Token = token
+		h.RoomManager.SetPlayerToken(code, p, token)
+		h.RoomManager.SetCharacter(code, p, t.character)
+		h.RoomManager.SetProfile(code, p, t.profileID)
+	}
+	h.rooms[code] = []*Client{a.client, b.client}
+
+	room, err = h.RoomManager.StartGame(code)
+	if err != nil {
+		log.Printf("failed to start ranked room %s: %v", code, err)
+		return
+	}
+
+	log.Printf("ranked room %s started (%s %.0f vs %s %.0f)", code, a.profileID, a.rating, b.profileID, b.rating)
+
+	g := h.RoomManager.Game(code)
+	match, _ := h.RoomManager.GetMatch(code)
+	for _, t := range tickets {
+		t.client.SendMessage(OutgoingMessage{
+			Type:         MsgMatchFound,
+			Code:         code,
+			Token:        t.client.Token,
+			PlayerNumber: t.client.PlayerNumber,
+			Characters:   room.SeatedCharacters(),
+		})
+		t.client.SendMessage(gameStartMessage(room, g, match))
+	}
+}
+
+// applyRatings updates the ranked ratings for a finished ranked game and
+// returns the new ratings by seat. Callers must hold h.mu.
+func (h *Hub) applyRatings(room *game.Room, result *game.GameResult) []int {
+	winnerID := room.ProfileIDs[result.Winner]
+	loserID := room.ProfileIDs[result.Loser]
+	if winnerID == "" || loserID == "" {
+		return nil
+	}
+
+	winnerRating, loserRating, err := h.Profiles.ApplyResult(winnerID, loserID)
+	if err != nil {
+		log.Printf("failed to update ratings: %v", err)
+		return nil
+	}
+	go func() {
+		if err := h.Profiles.Save(); err != nil {
+			log.Printf("failed to save ratings: %v", err)
+		}
+	}()
+
+	ratings := make([]int, 2)
+	ratings[result.Winner] = int(math.Round(winnerRating))
+	ratings[result.Loser] = int(math.Round(loserRating))
+	return ratings
+}
@@ -0,0 +1,227 @@
+package ws
+
+import (
+	"log"
+	"time"
+
+	"umineko_minesweeper/internal/game"
+	"umineko_minesweeper/internal/replay"
+)
+
+func (h *Hub) handleReplay(client *Client, id string, speed float64) {
+	if client.RoomCode != "" {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: "already in a game",
+		})
+		return
+	}
+
+	if speed == 0 {
+		speed = 1
+	}
+	if speed < 0 || speed > maxReplaySpeed {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: "invalid replay speed",
+		})
+		return
+	}
+
+	rec, err := h.Replays.Load(id)
+	if err != nil {
+		client.SendMessage(OutgoingMessage{
+			Type:    MsgError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	stop := make(chan struct{})
+	func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if client.replayStop != nil {
+			close(client.replayStop)
+		}
+		client.replayStop = stop
+	}()
+
+	log.Printf("streaming replay %s at %.2fx", rec.ID, speed)
+	go h.streamReplay(client, rec, speed, stop)
+}
+
+func (h *Hub) handleStopReplay(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if client.replayStop != nil {
+		close(client.replayStop)
+		client.replayStop = nil
+	}
+}
+
+func (h *Hub) streamReplay(client *Client, rec *replay.Replay, speed float64, stop chan struct{}) {
+	defer func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if client.replayStop == stop {
+			client.replayStop = nil
+		}
+	}()
+
+	start := OutgoingMessage{
+		Type:       MsgReplayStart,
+		Code:       rec.Code,
+		Mode:       rec.Mode,
+		Width:      rec.Width,
+		Height:     rec.Height,
+		Mines:      rec.Mines,
+		Characters: rec.Characters,
+		NoGuess:    rec.NoGuess,
+		TimeLimit:  rec.TimeLimit,
+		Scoring:    rec.Scoring,
+		Seed:       rec.Seed,
+		Round:      rec.Round,
+		ReplayID:   rec.ID,
+		Speed:      speed,
+	}
+	if rec.Lives > 1 {
+		start.Lives = make([]int, len(rec.Characters))
+		for p := range start.Lives {
+			start.Lives[p] = rec.Lives
+		}
+	}
+	if !h.sendReplayMessage(client, stop, start) {
+		return
+	}
+
+	var last int64
+	for _, action := range rec.Actions {
+		wait := time.Duration(float64(time.Duration(action.At-last)*time.Millisecond) / speed)
+		last = action.At
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(wait):
+		}
+
+		if !h.sendReplayMessage(client, stop, actionMessage(action)) {
+			return
+		}
+	}
+
+	if rec.Result != nil {
+		msg := OutgoingMessage{
+			Type:         MsgGameOver,
+			Winner:       rec.Result.Winner,
+			Loser:        rec.Result.Loser,
+			Cleared:      rec.Result.Cleared,
+			Placements:   rec.Result.Placements,
+			Times:        rec.Result.Times,
+			PlayerScores: rec.Result.Scores,
+			Hints:        rec.Result.Hints,
+			FlagCounts:   rec.Result.Flags,
+			Reason:       string(rec.Result.Reason),
+			Seed:         rec.Seed,
+			ReplayID:     rec.ID,
+		}
+		if rec.Result.Reason == game.ReasonMineHit {
+			msg.MineCells = rec.MineCells
+		}
+		if !h.sendReplayMessage(client, stop, msg) {
+			return
+		}
+	}
+
+	h.sendReplayMessage(client, stop, OutgoingMessage{
+		Type:     MsgReplayEnd,
+		ReplayID: rec.ID,
+	})
+}
+
+// sendReplayMessage only delivers while the client is still connected and the
+// replay has not been stopped, since the send channel is closed on disconnect.
+func (h *Hub) sendReplayMessage(client *Client, stop chan struct{}, msg OutgoingMessage) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	select {
+	case <-stop:
+		return false
+	default:
+	}
+	if !h.clients[client] {
+		return false
+	}
+	client.SendMessage(msg)
+	return true
+}
+
+func actionMessage(action game.Action) OutgoingMessage {
+	switch action.Type {
+	case game.ActionFlag:
+		return OutgoingMessage{
+			Type:    MsgCellFlagged,
+			Player:  action.Player,
+			X:       action.X,
+			Y:       action.Y,
+			Flagged: action.Flagged,
+		}
+	case game.ActionForfeit:
+		return OutgoingMessage{
+			Type:   MsgPlayerEliminated,
+			Player: action.Player,
+		}
+	case game.ActionHint:
+		return OutgoingMessage{
+			Type:   MsgHint,
+			Player: action.Player,
+			Hint:   &game.Hint{X: action.X, Y: action.Y},
+		}
+	case game.ActionAbility:
+		msg := OutgoingMessage{
+			Type:    MsgAbilityUsed,
+			Player:  action.Player,
+			Target:  action.Target,
+			Ability: action.Ability,
+			X:       action.X,
+			Y:       action.Y,
+			Cells:   action.Cells,
+		}
+		if action.Ability == game.AbilityHide {
+			msg.Countdown = int(game.HideDuration.Seconds())
+		}
+		return msg
+	}
+	return OutgoingMessage{
+		Type:   MsgCellsRevealed,
+		Player: action.Player,
+		Cells:  action.Cells,
+	}
+}
+
+// saveReplay stores a finished game and its analysis off the hub lock, then
+// hands the report to whichever of its players and spectators are still
+// connected.
+func (h *Hub) saveReplay(clients []*Client, rec *replay.Replay) {
+	if err := h.Replays.Save(rec); err != nil {
+		log.Printf("failed to save replay %s: %v", rec.ID, err)
+	}
+	analysis := replay.Analyze(rec)
+	if err := h.Replays.SaveAnalysis(analysis); err != nil {
+		log.Printf("failed to save analysis of replay %s: %v", rec.ID, err)
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, c := range clients {
+		if h.clients[c] {
+			c.SendMessage(OutgoingMessage{
+				Type:     MsgGameAnalysis,
+				ReplayID: rec.ID,
+				Analysis: analysis,
+			})
+		}
+	}
+}
@@ -3,8 +3,14 @@ package main
 import (
 	"embed"
 	"log"
+	"os"
+	"path/filepath"
 
+	"umineko_minesweeper/internal/character"
 	"umineko_minesweeper/internal/game"
+	"umineko_minesweeper/internal/leaderboard"
+	"umineko_minesweeper/internal/profile"
+	"umineko_minesweeper/internal/replay"
 	"umineko_minesweeper/internal/server"
 	"umineko_minesweeper/internal/ws"
 )
@@ -13,8 +19,33 @@ import (
 var staticFiles embed.FS
 
 func main() {
-	rm := game.NewRoomManager()
-	hub := ws.NewHub(rm)
+	dataDir := os.Getenv("DATA_DIR")
+	if dataDir == "" {
+		dataDir = "data"
+	}
+
+	replays, err := replay.NewStore(filepath.Join(dataDir, "replays"))
+	if err != nil {
+		log.Fatalf("failed to open replay store: %v", err)
+	}
+
+	profiles, err := profile.Open(filepath.Join(dataDir, "players.json"))
+	if err != nil {
+		log.Fatalf("failed to open player store: %v", err)
+	}
+
+	leaderboards, err := leaderboard.Open(filepath.Join(dataDir, "leaderboards.json"))
+	if err != nil {
+		log.Fatalf("failed to open leaderboard store: %v", err)
+	}
+
+	characters, err := character.Load(os.Getenv("CHARACTERS_FILE"))
+	if err != nil {
+		log.Fatalf("failed to load characters: %v", err)
+	}
+
+	rm := game.NewRoomManager(characters.Abilities())
+	hub := ws.NewHub(rm, replays, profiles, leaderboards, characters)
 	go hub.Run()
 
 	srv := server.New(hub, staticFiles)